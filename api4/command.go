@@ -0,0 +1,257 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api4
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mattermost/mattermost-server/v6/audit"
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+func (api *API) InitCommand() {
+	api.BaseRoutes.Commands.Handle("", api.ApiSessionRequired(createCommand)).Methods("POST")
+	api.BaseRoutes.Commands.Handle("/execute", api.ApiSessionRequired(executeCommand)).Methods("POST")
+	api.BaseRoutes.Commands.Handle("/responses/{token}", api.ApiHandler(postCommandResponse)).Methods("POST")
+	api.BaseRoutes.Commands.Handle("/{command_id:[A-Za-z0-9]+}", api.ApiSessionRequired(updateCommand)).Methods("PUT")
+	api.BaseRoutes.Commands.Handle("/{command_id:[A-Za-z0-9]+}/health", api.ApiSessionRequired(getCommandHealth)).Methods("GET")
+	api.BaseRoutes.Commands.Handle("/{command_id:[A-Za-z0-9]+}/regen_signing_secret", api.ApiSessionRequired(regenCommandSigningSecret)).Methods("PUT")
+	api.BaseRoutes.Commands.Handle("/actions/{action_id:[A-Za-z0-9]+}", api.ApiSessionRequired(doPostAction)).Methods("POST")
+}
+
+// createCommand registers a new slash command. A command with TeamId set
+// requires manage_slash_commands on that team; an empty TeamId creates a
+// global command reachable from every team (see
+// App.EnsureCommandCreatePermission), which requires manage_system
+// instead.
+func createCommand(c *Context, w http.ResponseWriter, r *http.Request) {
+	cmd := model.CommandFromJson(r.Body)
+	if cmd == nil {
+		c.SetInvalidParam("command")
+		return
+	}
+
+	auditRec := c.MakeAuditRecord("createCommand", audit.Fail)
+	defer c.LogAuditRec(auditRec)
+	auditRec.AddMeta("command", cmd)
+
+	if appErr := c.App.EnsureCommandCreatePermission(*c.AppContext.Session(), cmd); appErr != nil {
+		c.Err = appErr
+		return
+	}
+
+	cmd.CreatorId = c.AppContext.Session().UserId
+
+	rcmd, err := c.App.CreateCommand(cmd)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+	auditRec.AddMeta("command", rcmd)
+
+	w.WriteHeader(http.StatusCreated)
+	if jsonErr := json.NewEncoder(w).Encode(rcmd); jsonErr != nil {
+		mlog.Warn("Error while writing response", mlog.Err(jsonErr))
+	}
+}
+
+// updateCommand applies an in-place edit to an existing command, requiring
+// manage_slash_commands on the command's own team.
+func updateCommand(c *Context, w http.ResponseWriter, r *http.Request) {
+	commandId := mux.Vars(r)["command_id"]
+	if !model.IsValidId(commandId) {
+		c.SetInvalidUrlParam("command_id")
+		return
+	}
+
+	updatedCmd := model.CommandFromJson(r.Body)
+	if updatedCmd == nil {
+		c.SetInvalidParam("command")
+		return
+	}
+
+	oldCmd, err := c.App.GetCommand(commandId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(*c.AppContext.Session(), oldCmd.TeamId, model.PermissionManageSlashCommands) {
+		c.SetPermissionError(model.PermissionManageSlashCommands)
+		return
+	}
+
+	auditRec := c.MakeAuditRecord("updateCommand", audit.Fail)
+	defer c.LogAuditRec(auditRec)
+	auditRec.AddMeta("command", oldCmd)
+
+	rcmd, err := c.App.UpdateCommand(oldCmd, updatedCmd)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+	auditRec.AddMeta("update", rcmd)
+
+	if jsonErr := json.NewEncoder(w).Encode(rcmd); jsonErr != nil {
+		mlog.Warn("Error while writing response", mlog.Err(jsonErr))
+	}
+}
+
+// executeCommand runs the slash command embedded in the posted
+// CommandArgs against the channel it names, requiring
+// use_slash_commands on that channel.
+func executeCommand(c *Context, w http.ResponseWriter, r *http.Request) {
+	cmdArgs := model.CommandArgsFromJson(r.Body)
+	if cmdArgs == nil || !model.IsValidId(cmdArgs.ChannelId) || cmdArgs.Command == "" {
+		c.SetInvalidParam("command_args")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(*c.AppContext.Session(), cmdArgs.ChannelId, model.PermissionUseSlashCommands) {
+		c.SetPermissionError(model.PermissionUseSlashCommands)
+		return
+	}
+
+	cmdArgs.UserId = c.AppContext.Session().UserId
+	cmdArgs.SiteURL = c.GetSiteURLHeader()
+
+	response, err := c.App.ExecuteCommand(cmdArgs)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if jsonErr := json.NewEncoder(w).Encode(response); jsonErr != nil {
+		mlog.Warn("Error while writing response", mlog.Err(jsonErr))
+	}
+}
+
+// postCommandResponse lets an integration that couldn't reply within the
+// command's original request timeout deliver one or more follow-up
+// CommandResponse payloads afterward, via the response URL the server
+// handed it when the command executed.
+func postCommandResponse(c *Context, w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+	if token == "" {
+		c.SetInvalidUrlParam("token")
+		return
+	}
+
+	response, jsonErr := model.CommandResponseFromJson(r.Body)
+	if jsonErr != nil || response == nil {
+		c.SetInvalidParam("command_response")
+		return
+	}
+
+	if err := c.App.DeliverCommandResponse(token, response); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
+
+// getCommandHealth reports a command's circuit breaker state: whether it's
+// currently closed (healthy) or open (short-circuiting requests during a
+// cool-down window after repeated failures), along with the consecutive
+// failure count and the most recent error.
+func getCommandHealth(c *Context, w http.ResponseWriter, r *http.Request) {
+	commandId := mux.Vars(r)["command_id"]
+	if !model.IsValidId(commandId) {
+		c.SetInvalidUrlParam("command_id")
+		return
+	}
+
+	cmd, err := c.App.GetCommand(commandId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(*c.AppContext.Session(), cmd.TeamId, model.PermissionManageSlashCommands) {
+		c.SetPermissionError(model.PermissionManageSlashCommands)
+		return
+	}
+
+	status := c.App.GetCommandBreakerStatus(cmd)
+
+	w.Write([]byte(status.ToJson()))
+}
+
+// regenCommandSigningSecret issues a new HMAC signing secret for a
+// command, returning it in the response body -- the only time the caller
+// will ever see it, just like regenerating the command's token.
+func regenCommandSigningSecret(c *Context, w http.ResponseWriter, r *http.Request) {
+	commandId := mux.Vars(r)["command_id"]
+	if !model.IsValidId(commandId) {
+		c.SetInvalidUrlParam("command_id")
+		return
+	}
+
+	cmd, err := c.App.GetCommand(commandId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(*c.AppContext.Session(), cmd.TeamId, model.PermissionManageSlashCommands) {
+		// Not found rather than forbidden, as for token regeneration, so the
+		// command's existence isn't revealed to someone without access.
+		c.Err = model.NewAppError("regenCommandSigningSecret", "app.command.get_command.missing.app_error", nil, "command_id="+commandId, http.StatusNotFound)
+		return
+	}
+
+	auditRec := c.MakeAuditRecord("regenCommandSigningSecret", audit.Fail)
+	defer c.LogAuditRec(auditRec)
+	auditRec.AddMeta("command_id", commandId)
+
+	updated, err := c.App.RegenCommandSigningSecret(commandId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	resp := map[string]string{"signing_secret": updated.SigningSecret}
+	w.Write([]byte(model.MapToJson(resp)))
+}
+
+type doPostActionRequest struct {
+	PostId string `json:"post_id"`
+}
+
+// doPostAction handles a click on an interactive PostAction attached to a
+// command response: it looks up the action's owning post, invokes its
+// Integration, and applies whatever the integration returns back to the
+// post before replying to the clicking user.
+func doPostAction(c *Context, w http.ResponseWriter, r *http.Request) {
+	actionId := mux.Vars(r)["action_id"]
+	if !model.IsValidId(actionId) {
+		c.SetInvalidUrlParam("action_id")
+		return
+	}
+
+	var req doPostActionRequest
+	if jsonErr := json.NewDecoder(r.Body).Decode(&req); jsonErr != nil || !model.IsValidId(req.PostId) {
+		c.SetInvalidParam("post_id")
+		return
+	}
+
+	response, err := c.App.DoPostAction(req.PostId, actionId, c.AppContext.Session().UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write(response.ToJson())
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api4
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// TestExecuteCommandFormContentType covers the default (form-urlencoded)
+// outgoing request mode end-to-end through the real HTTP API, rather than
+// calling the unexported executeCommandRequest directly -- see
+// app.TestExecuteCommandRequestFormContentType for the lower-level unit
+// test this complements.
+func TestExecuteCommandFormContentType(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+	client := th.Client
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	allowedInternalConnections := *th.App.Config().ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.AllowedUntrustedInternalConnections = &allowedInternalConnections
+		})
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.0/8"
+	})
+
+	var gotContentType string
+	var gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text":"form ok","response_type":"in_channel"}`))
+	}))
+	defer ts.Close()
+
+	cmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       ts.URL,
+		Method:    model.CommandMethodPost,
+		Trigger:   "formmode",
+	}
+	_, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	response, resp := client.ExecuteCommand(th.BasicChannel.Id, "/formmode hello")
+	CheckOKStatus(t, resp)
+	require.Equal(t, "form ok", response.Text)
+
+	require.Equal(t, model.CommandContentTypeForm, gotContentType)
+	require.Contains(t, gotBody, "text=hello")
+}
+
+// TestExecuteCommandJSONContentTypeIsSigned covers the JSON outgoing
+// request mode and its HMAC signature end-to-end through the real HTTP
+// API, complementing app.TestExecuteCommandRequestJSONContentType and
+// app.TestExecuteCommandRequestJSONSignsRawBody.
+func TestExecuteCommandJSONContentTypeIsSigned(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+	client := th.Client
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	allowedInternalConnections := *th.App.Config().ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.AllowedUntrustedInternalConnections = &allowedInternalConnections
+		})
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.0/8"
+	})
+
+	const signingSecret = "json-mode-secret"
+
+	var gotContentType string
+	var signatureValid bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		b, _ := io.ReadAll(r.Body)
+		signatureValid = model.VerifySlashCommandSignature(signingSecret, r.Header, b)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text":"json ok","response_type":"in_channel"}`))
+	}))
+	defer ts.Close()
+
+	cmd := &model.Command{
+		CreatorId:        th.BasicUser.Id,
+		TeamId:           th.BasicTeam.Id,
+		URL:              ts.URL,
+		Method:           model.CommandMethodPost,
+		Trigger:          "jsonmode",
+		ContentType:      model.CommandContentTypeJSON,
+		SigningSecret:    signingSecret,
+		SigningAlgorithm: model.CommandSigningAlgorithmHMACSHA256,
+	}
+	_, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	response, resp := client.ExecuteCommand(th.BasicChannel.Id, "/jsonmode hello")
+	CheckOKStatus(t, resp)
+	require.Equal(t, "json ok", response.Text)
+
+	require.Equal(t, model.CommandContentTypeJSON, gotContentType)
+	require.True(t, signatureValid)
+}
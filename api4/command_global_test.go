@@ -0,0 +1,162 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api4
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+func TestCreateGlobalCommandRequiresManageSystem(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	defer th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+
+	globalCmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    "",
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "globalcreate",
+	}
+
+	_, resp := th.Client.CreateCommand(globalCmd)
+	CheckForbiddenStatus(t, resp)
+
+	created, resp := th.SystemAdminClient.CreateCommand(globalCmd)
+	CheckNoError(t, resp)
+	require.Empty(t, created.TeamId)
+}
+
+func TestCreateGlobalCommandTriggerCollidesWithTeamCommand(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	defer th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+
+	teamCmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "collidingtrigger",
+	}
+	_, resp := th.SystemAdminClient.CreateCommand(teamCmd)
+	CheckNoError(t, resp)
+
+	globalCmd := &model.Command{
+		CreatorId: th.SystemAdminUser.Id,
+		TeamId:    "",
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "collidingtrigger",
+	}
+	_, resp = th.SystemAdminClient.CreateCommand(globalCmd)
+	CheckBadRequestStatus(t, resp)
+	CheckErrorMessage(t, resp, "api.command.duplicate_trigger.app_error")
+}
+
+func TestExecuteGlobalCommandFromChannelOnTeam(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+	client := th.Client
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	allowedInternalConnections := *th.App.Config().ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.AllowedUntrustedInternalConnections = &allowedInternalConnections
+		})
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.0/8" })
+
+	expectedCommandResponse := &model.CommandResponse{
+		Text:         "global command response",
+		ResponseType: model.CommandResponseTypeInChannel,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(expectedCommandResponse); err != nil {
+			mlog.Warn("Error while writing response", mlog.Err(err))
+		}
+	}))
+	defer ts.Close()
+
+	globalCmd := &model.Command{
+		CreatorId: th.SystemAdminUser.Id,
+		TeamId:    "",
+		URL:       ts.URL,
+		Method:    model.CommandMethodPost,
+		Trigger:   "globalrun",
+	}
+	_, err := th.App.CreateCommand(globalCmd)
+	require.Nil(t, err)
+
+	_, resp := client.ExecuteCommand(th.BasicChannel.Id, "/globalrun")
+	CheckOKStatus(t, resp)
+}
+
+func TestExecuteGlobalCommandInDirectMessageChannel(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+	client := th.Client
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	allowedInternalConnections := *th.App.Config().ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.AllowedUntrustedInternalConnections = &allowedInternalConnections
+		})
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.AllowedUntrustedInternalConnections = "localhost,127.0.0.1"
+	})
+
+	expectedCommandResponse := &model.CommandResponse{
+		Text:         "global dm response",
+		ResponseType: model.CommandResponseTypeInChannel,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(expectedCommandResponse); err != nil {
+			mlog.Warn("Error while writing response", mlog.Err(err))
+		}
+	}))
+	defer ts.Close()
+
+	globalCmd := &model.Command{
+		CreatorId: th.SystemAdminUser.Id,
+		TeamId:    "",
+		URL:       ts.URL,
+		Method:    model.CommandMethodPost,
+		Trigger:   "globaldm",
+	}
+	_, err := th.App.CreateCommand(globalCmd)
+	require.Nil(t, err)
+
+	dmChannel, response := client.CreateDirectChannel(th.BasicUser.Id, th.BasicUser2.Id)
+	CheckCreatedStatus(t, response)
+
+	// A global command needs no team_id at all to run from a DM channel.
+	_, resp := client.ExecuteCommand(dmChannel.Id, "/globaldm")
+	CheckOKStatus(t, resp)
+}
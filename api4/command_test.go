@@ -9,7 +9,9 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -603,6 +605,41 @@ func TestRegenToken(t *testing.T) {
 	require.Empty(t, token, "should not return the token")
 }
 
+func TestRegenCommandSigningSecret(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+
+	newCmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "signedtrigger"}
+
+	createdCmd, resp := th.SystemAdminClient.CreateCommand(newCmd)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+
+	secret, resp := th.SystemAdminClient.RegenCommandSigningSecret(createdCmd.Id)
+	CheckNoError(t, resp)
+	require.NotEmpty(t, secret)
+
+	secondSecret, resp := th.SystemAdminClient.RegenCommandSigningSecret(createdCmd.Id)
+	CheckNoError(t, resp)
+	require.NotEqual(t, secret, secondSecret, "regenerating again should produce a different secret")
+
+	secret, resp = Client.RegenCommandSigningSecret(createdCmd.Id)
+	CheckNotFoundStatus(t, resp)
+	require.Empty(t, secret, "should not return the secret")
+}
+
 func TestExecuteInvalidCommand(t *testing.T) {
 	th := Setup(t).InitBasic()
 	defer th.TearDown()
@@ -1028,3 +1065,241 @@ func TestExecuteCommandInTeamUserIsNotOn(t *testing.T) {
 	_, resp = client.ExecuteCommand(dmChannel.Id, "/postcommand")
 	CheckForbiddenStatus(t, resp)
 }
+
+func TestExecuteCommandInGroupMessageChannel(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+	client := th.Client
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	allowedInternalConnections := *th.App.Config().ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.AllowedUntrustedInternalConnections = &allowedInternalConnections
+		})
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.AllowedUntrustedInternalConnections = "localhost,127.0.0.1"
+	})
+
+	expectedCommandResponse := &model.CommandResponse{
+		Text:         "test gm command response",
+		ResponseType: model.CommandResponseTypeInChannel,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(expectedCommandResponse)
+	}))
+	defer ts.Close()
+
+	postCmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       ts.URL,
+		Method:    model.CommandMethodPost,
+		Trigger:   "gmcommand",
+	}
+	_, err := th.App.CreateCommand(postCmd)
+	require.Nil(t, err, "failed to create gm command")
+
+	thirdUser := th.CreateUser()
+	th.LinkUserToTeam(thirdUser, th.BasicTeam)
+
+	gmChannel, response := client.CreateGroupChannel([]string{th.BasicUser.Id, th.BasicUser2.Id, thirdUser.Id})
+	CheckCreatedStatus(t, response)
+
+	// with no team id supplied, the server picks the team shared by the
+	// invoking user and the command's creator automatically
+	_, resp := client.ExecuteCommand(gmChannel.Id, "/gmcommand")
+	CheckOKStatus(t, resp)
+
+	// if the invoking user is removed from the supplied team, the command
+	// can no longer be run in the GM channel against that team
+	success, _ := client.RemoveTeamMember(th.BasicTeam.Id, th.BasicUser.Id)
+	require.True(t, success, "Failed to remove user from team")
+
+	_, resp = client.ExecuteCommandWithTeam(gmChannel.Id, th.BasicTeam.Id, "/gmcommand")
+	CheckForbiddenStatus(t, resp)
+}
+
+func TestExecuteCommandInGroupMessageChannelNoSharedTeam(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+	client := th.Client
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	allowedInternalConnections := *th.App.Config().ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.AllowedUntrustedInternalConnections = &allowedInternalConnections
+		})
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.AllowedUntrustedInternalConnections = "localhost,127.0.0.1"
+	})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&model.CommandResponse{Text: "unreachable"})
+	}))
+	defer ts.Close()
+
+	// the command's creator belongs only to a team the invoking user isn't on
+	creator := th.CreateUser()
+	creatorOnlyTeam := th.CreateTeam()
+	th.LinkUserToTeam(creator, creatorOnlyTeam)
+
+	postCmd := &model.Command{
+		CreatorId: creator.Id,
+		TeamId:    creatorOnlyTeam.Id,
+		URL:       ts.URL,
+		Method:    model.CommandMethodPost,
+		Trigger:   "noshareteamcommand",
+	}
+	_, err := th.App.CreateCommand(postCmd)
+	require.Nil(t, err, "failed to create command")
+
+	gmChannel, response := client.CreateGroupChannel([]string{th.BasicUser.Id, th.BasicUser2.Id})
+	CheckCreatedStatus(t, response)
+
+	_, resp := client.ExecuteCommand(gmChannel.Id, "/noshareteamcommand")
+	CheckNotFoundStatus(t, resp)
+}
+
+func TestExecuteCommandRetriesOnFlakyUpstream(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	allowedInternalConnections := *th.App.Config().ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.AllowedUntrustedInternalConnections = &allowedInternalConnections
+		})
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.0/8" })
+
+	expectedCommandResponse := &model.CommandResponse{
+		Text:         "recovered after retry",
+		ResponseType: model.CommandResponseTypeInChannel,
+	}
+
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(expectedCommandResponse)
+	}))
+	defer ts.Close()
+
+	flakyCmd := &model.Command{
+		CreatorId:      th.BasicUser.Id,
+		TeamId:         th.BasicTeam.Id,
+		URL:            ts.URL,
+		Method:         model.CommandMethodPost,
+		Trigger:        "flakycommand",
+		MaxRetries:     2,
+		RetryBackoffMs: 1,
+	}
+	_, err := th.App.CreateCommand(flakyCmd)
+	require.Nil(t, err, "failed to create flaky command")
+
+	commandResponse, resp := Client.ExecuteCommand(channel.Id, "/flakycommand")
+	CheckNoError(t, resp)
+	require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	require.Equal(t, expectedCommandResponse.Text, commandResponse.Text)
+}
+
+func TestExecuteCommandHonorsTimeout(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	allowedInternalConnections := *th.App.Config().ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.AllowedUntrustedInternalConnections = &allowedInternalConnections
+		})
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.0/8" })
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Second)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&model.CommandResponse{Text: "too slow"})
+	}))
+	defer ts.Close()
+
+	slowCmd := &model.Command{
+		CreatorId:      th.BasicUser.Id,
+		TeamId:         th.BasicTeam.Id,
+		URL:            ts.URL,
+		Method:         model.CommandMethodPost,
+		Trigger:        "slowcommand",
+		TimeoutSeconds: 1,
+		RetryBackoffMs: 1,
+	}
+	_, err := th.App.CreateCommand(slowCmd)
+	require.Nil(t, err, "failed to create slow command")
+
+	_, resp := Client.ExecuteCommand(channel.Id, "/slowcommand")
+	CheckInternalServerError(t, resp)
+}
+
+func TestCommandHealthReflectsBreakerState(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	enableCommands := *th.App.Config().ServiceSettings.EnableCommands
+	allowedInternalConnections := *th.App.Config().ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.EnableCommands = &enableCommands })
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.AllowedUntrustedInternalConnections = &allowedInternalConnections
+		})
+	}()
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCommands = true })
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.0/8" })
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	failingCmd := &model.Command{
+		CreatorId:      th.BasicUser.Id,
+		TeamId:         th.BasicTeam.Id,
+		URL:            ts.URL,
+		Method:         model.CommandMethodPost,
+		Trigger:        "failingcommand",
+		RetryBackoffMs: 1,
+	}
+	failingCmd, err := th.App.CreateCommand(failingCmd)
+	require.Nil(t, err, "failed to create failing command")
+
+	for i := 0; i < 3; i++ {
+		th.SystemAdminClient.ExecuteCommand(th.BasicChannel.Id, "/failingcommand")
+	}
+
+	status, resp := th.SystemAdminClient.GetCommandHealth(failingCmd.Id)
+	CheckNoError(t, resp)
+	require.Equal(t, model.CommandBreakerStateOpen, status.State)
+	require.GreaterOrEqual(t, status.ConsecutiveFailures, 3)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api4
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/shared/mlog"
+)
+
+func (api *API) InitCompliance() {
+	api.BaseRoutes.Compliance.Handle("/reports/{report_id:[A-Za-z0-9]+}/stream", api.ApiSessionRequired(streamComplianceReport)).Methods("GET")
+}
+
+// streamComplianceReport lets an external SIEM/eDiscovery tool resume a
+// paginated compliance export. It returns NDJSON (one CompliancePost per
+// line) so multi-gigabyte exports can be piped through jq/Logstash
+// without buffering the whole thing in memory, and it honors the same
+// permission model as the rest of the compliance endpoints.
+func streamComplianceReport(c *Context, w http.ResponseWriter, r *http.Request) {
+	reportId := mux.Vars(r)["report_id"]
+	if !model.IsValidId(reportId) {
+		c.SetInvalidUrlParam("report_id")
+		return
+	}
+
+	if !c.App.SessionHasPermissionTo(*c.AppContext.Session(), model.PermissionReadCompliance) {
+		c.SetPermissionError(model.PermissionReadCompliance)
+		return
+	}
+
+	report, err := c.App.GetComplianceReport(reportId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	cursor, decodeErr := model.DecodeComplianceExportCursor(report.Id, r.URL.Query().Get("cursor"))
+	if decodeErr != nil {
+		c.SetInvalidParam("cursor")
+		return
+	}
+
+	posts, nextCursor, err := c.App.GetComplianceExportPage(report, *cursor)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	nextToken, encodeErr := model.EncodeComplianceExportCursor(report.Id, nextCursor)
+	if encodeErr != nil {
+		c.Err = model.NewAppError("streamComplianceReport", "api.compliance.stream_compliance_report.cursor.app_error", nil, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Mattermost-Compliance-Cursor", nextToken)
+	w.Header().Set("X-Mattermost-Compliance-Completed", boolHeaderValue(nextCursor.IsComplete()))
+
+	encoder := json.NewEncoder(w)
+	for _, post := range posts {
+		if err := encoder.Encode(post); err != nil {
+			mlog.Warn("Error while writing response", mlog.Err(err))
+			return
+		}
+	}
+}
+
+func boolHeaderValue(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
@@ -30,6 +30,7 @@ func (api *API) InitEmoji() {
 	api.BaseRoutes.Emoji.Handle("", api.ApiSessionRequired(getEmoji)).Methods("GET")
 	api.BaseRoutes.EmojiByName.Handle("", api.ApiSessionRequired(getEmojiByName)).Methods("GET")
 	api.BaseRoutes.Emoji.Handle("/image", api.ApiSessionRequiredTrustRequester(getEmojiImage)).Methods("GET")
+	api.BaseRoutes.Emoji.Handle("/tags", api.ApiSessionRequired(updateEmojiTags)).Methods("POST")
 }
 
 func createEmoji(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -266,7 +267,7 @@ func searchEmojis(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	emojis, err := c.App.SearchEmoji(emojiSearch.Term, emojiSearch.PrefixOnly, web.PerPageMaximum)
+	emojis, err := c.App.SearchEmojiFaceted(emojiSearch.Term, emojiSearch.PrefixOnly, emojiSearch.Category, emojiSearch.Tags, web.PerPageMaximum)
 	if err != nil {
 		c.Err = err
 		return
@@ -285,7 +286,13 @@ func autocompleteEmojis(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	emojis, err := c.App.SearchEmoji(name, true, EmojiMaxAutocompleteItems)
+	category := r.URL.Query().Get("category")
+	var tags []string
+	if tagsParam := r.URL.Query().Get("tags"); tagsParam != "" {
+		tags = strings.Split(tagsParam, ",")
+	}
+
+	emojis, err := c.App.SearchEmojiFaceted(name, true, category, tags, EmojiMaxAutocompleteItems)
 	if err != nil {
 		c.Err = err
 		return
@@ -295,3 +302,46 @@ func autocompleteEmojis(c *Context, w http.ResponseWriter, r *http.Request) {
 		mlog.Warn("Error while writing response", mlog.Err(err))
 	}
 }
+
+// updateEmojiTags lets the emoji's creator, or anyone with
+// PermissionManageOthersEmojis, set the free-form tags used by faceted
+// search and autocomplete.
+func updateEmojiTags(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireEmojiId()
+	if c.Err != nil {
+		return
+	}
+
+	patch := model.EmojiTagsPatchFromJson(r.Body)
+	if patch == nil {
+		c.SetInvalidParam("tags")
+		return
+	}
+
+	emoji, err := c.App.GetEmoji(c.Params.EmojiId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if c.AppContext.Session().UserId != emoji.CreatorId && !c.App.SessionHasPermissionTo(*c.AppContext.Session(), model.PermissionManageOthersEmojis) {
+		c.SetPermissionError(model.PermissionManageOthersEmojis)
+		return
+	}
+
+	auditRec := c.MakeAuditRecord("updateEmojiTags", audit.Fail)
+	defer c.LogAuditRec(auditRec)
+	auditRec.AddMeta("emoji", emoji)
+
+	updated, err := c.App.UpdateEmojiTags(emoji, patch.Tags)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	if err := json.NewEncoder(w).Encode(updated); err != nil {
+		mlog.Warn("Error while writing response", mlog.Err(err))
+	}
+}
@@ -23,6 +23,8 @@ func (api *API) InitRole() {
 	api.BaseRoutes.Roles.Handle("/name/{role_name:[a-z0-9_]+}", api.ApiSessionRequiredTrustRequester(getRoleByName)).Methods("GET")
 	api.BaseRoutes.Roles.Handle("/names", api.ApiSessionRequiredTrustRequester(getRolesByNames)).Methods("POST")
 	api.BaseRoutes.Roles.Handle("/{role_id:[A-Za-z0-9]+}/patch", api.ApiSessionRequired(patchRole)).Methods("PUT")
+	api.BaseRoutes.Roles.Handle("/export", api.ApiSessionRequired(exportRoles)).Methods("POST")
+	api.BaseRoutes.Roles.Handle("/import", api.ApiSessionRequired(importRoles)).Methods("POST")
 }
 
 func getRole(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -117,14 +119,6 @@ func patchRole(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	isGuest := oldRole.Name == model.SystemGuestRoleId || oldRole.Name == model.TeamGuestRoleId || oldRole.Name == model.ChannelGuestRoleId
-	if c.App.Srv().License() == nil && patch.Permissions != nil {
-		if isGuest {
-			c.Err = model.NewAppError("Api4.PatchRoles", "api.roles.patch_roles.license.error", nil, "", http.StatusNotImplemented)
-			return
-		}
-	}
-
 	// Licensed instances can not change permissions in the blacklist set.
 	if patch.Permissions != nil {
 		deltaPermissions := model.PermissionsChangedByPatch(oldRole, patch)
@@ -144,11 +138,11 @@ func patchRole(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 
 		*patch.Permissions = model.RemoveDuplicateStrings(*patch.Permissions)
-	}
 
-	if c.App.Srv().License() != nil && isGuest && !*c.App.Srv().License().Features.GuestAccountsPermissions {
-		c.Err = model.NewAppError("Api4.PatchRoles", "api.roles.patch_roles.license.error", nil, "", http.StatusNotImplemented)
-		return
+		if appErr := c.App.EnsureRolePermissionChangeAllowed(oldRole); appErr != nil {
+			c.Err = appErr
+			return
+		}
 	}
 
 	if oldRole.Name == model.TeamAdminRoleId || oldRole.Name == model.ChannelAdminRoleId || oldRole.Name == model.SystemUserRoleId || oldRole.Name == model.TeamUserRoleId || oldRole.Name == model.ChannelUserRoleId || oldRole.Name == model.SystemGuestRoleId || oldRole.Name == model.TeamGuestRoleId || oldRole.Name == model.ChannelGuestRoleId {
@@ -177,3 +171,59 @@ func patchRole(c *Context, w http.ResponseWriter, r *http.Request) {
 		mlog.Warn("Error while writing response", mlog.Err(err))
 	}
 }
+
+// exportRoles streams every role, including scheme-scoped roles, as a
+// versioned, signed JSON bundle so it can be checked into version control
+// and reapplied with importRoles.
+func exportRoles(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !c.App.SessionHasPermissionTo(*c.AppContext.Session(), model.PermissionManageSystem) {
+		c.SetPermissionError(model.PermissionManageSystem)
+		return
+	}
+
+	auditRec := c.MakeAuditRecord("exportRoles", audit.Fail)
+	defer c.LogAuditRec(auditRec)
+
+	bundle, err := c.App.ExportRoleBundle()
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	w.Write([]byte(bundle.ToJson()))
+}
+
+// importRoles applies a bundle previously produced by exportRoles. The
+// bundle's signature is verified before anything else (see
+// app.ImportRoleBundle), and for every role in the bundle it reuses the
+// same not-allowed-permission blacklist and guest/license gating that
+// patchRole enforces for a single role, and supports a dry-run mode that
+// returns the computed diff without mutating any role.
+func importRoles(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !c.App.SessionHasPermissionTo(*c.AppContext.Session(), model.PermissionManageSystem) {
+		c.SetPermissionError(model.PermissionManageSystem)
+		return
+	}
+
+	importRequest := model.RoleImportRequestFromJson(r.Body)
+	if importRequest == nil || importRequest.Bundle == nil {
+		c.SetInvalidParam("bundle")
+		return
+	}
+
+	auditRec := c.MakeAuditRecord("importRoles", audit.Fail)
+	defer c.LogAuditRec(auditRec)
+	auditRec.AddMeta("dry_run", importRequest.DryRun)
+
+	result, err := c.App.ImportRoleBundle(r.Context(), importRequest.Bundle, importRequest.DryRun)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	w.Write([]byte(result.ToJson()))
+}
@@ -0,0 +1,334 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// executeCommandRequest builds and sends the outgoing HTTP request for a
+// slash command, attaching the shared token as well as, when the command
+// has a SigningSecret configured, an HMAC-SHA256 signature computed over
+// the exact bytes being sent. The signature headers are always sent
+// alongside the legacy token so existing integrations keep working while
+// they migrate to verifying the signature instead.
+func (a *App) executeCommandRequest(cmd *model.Command, p url.Values) (*http.Request, error) {
+	if cmd.Method == model.CommandMethodGet {
+		req, err := http.NewRequest(http.MethodGet, cmd.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		q := req.URL.Query()
+		for key, vals := range p {
+			for _, v := range vals {
+				q.Add(key, v)
+			}
+		}
+		req.URL.RawQuery = q.Encode()
+
+		if cmd.SigningSecret != "" {
+			timestamp := model.GetMillis() / 1000
+			signature := model.ComputeCommandSignature(cmd.SigningSecret, timestamp, []byte(req.URL.RawQuery))
+			req.Header.Set(model.CommandSignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+			req.Header.Set(model.CommandSignatureHeader, signature)
+		}
+
+		return req, nil
+	}
+
+	var rawBody []byte
+	var contentType string
+
+	if cmd.ContentType == model.CommandContentTypeJSON {
+		payload := model.CommandPayloadFromValues(p)
+
+		marshaled, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		rawBody = marshaled
+		contentType = model.CommandContentTypeJSON
+	} else {
+		rawBody = []byte(p.Encode())
+		contentType = model.CommandContentTypeForm
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cmd.URL, bytes.NewReader(rawBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	if cmd.SigningSecret != "" {
+		timestamp := model.GetMillis() / 1000
+		signature := model.ComputeCommandSignature(cmd.SigningSecret, timestamp, rawBody)
+		req.Header.Set(model.CommandSignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(model.CommandSignatureHeader, signature)
+	}
+
+	return req, nil
+}
+
+// DoCommandRequest sends the request built by executeCommandRequest and
+// decodes the CommandResponse the integration replied with. It honors the
+// command's configured TimeoutSeconds, retries up to MaxRetries times
+// (waiting RetryBackoffMs between attempts) on timeout or a 5xx response,
+// and consults the command's circuit breaker before attempting, so a
+// consistently failing webhook stops being hammered once it trips open.
+// The effective timeout, retry count, and backoff are also capped at the
+// ceilings from commandTimeoutCeilingSeconds and its siblings, which are
+// the same hard maximums model.Command.IsValid checks; no ServiceSettings
+// value is read for them.
+func (a *App) DoCommandRequest(cmd *model.Command, p url.Values) (*model.CommandResponse, *model.AppError) {
+	breaker := getCommandBreaker(cmd.Id)
+	if !breaker.allow() {
+		return nil, model.NewAppError("DoCommandRequest", "api.command.execute_command.circuit_open.app_error", nil, "", http.StatusServiceUnavailable)
+	}
+
+	timeoutSeconds := cmd.TimeoutSeconds
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = model.CommandDefaultTimeoutSeconds
+	}
+	if ceiling := a.commandTimeoutCeilingSeconds(); timeoutSeconds > ceiling {
+		timeoutSeconds = ceiling
+	}
+	timeout := time.Duration(timeoutSeconds) * time.Second
+
+	maxRetries := cmd.MaxRetries
+	if ceiling := a.commandMaxRetriesCeiling(); maxRetries > ceiling {
+		maxRetries = ceiling
+	}
+
+	backoffMs := cmd.RetryBackoffMs
+	if ceiling := a.commandRetryBackoffCeilingMs(); backoffMs > ceiling {
+		backoffMs = ceiling
+	}
+	backoff := time.Duration(backoffMs) * time.Millisecond
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+		}
+
+		commandResponse, statusCode, err := a.doCommandRequestOnce(cmd, p, timeout)
+		if err == nil {
+			breaker.recordSuccess()
+			stampCommandIdOnActions(cmd, commandResponse)
+			return commandResponse, nil
+		}
+
+		lastErr = err
+		if statusCode > 0 && statusCode < http.StatusInternalServerError {
+			// A client-side error (4xx) from the webhook isn't transient;
+			// retrying the same request would just fail the same way.
+			break
+		}
+	}
+
+	breaker.recordFailure(lastErr.Error())
+	return nil, model.NewAppError("DoCommandRequest", "api.command.execute_command.failed.app_error", nil, lastErr.Error(), http.StatusInternalServerError)
+}
+
+// doCommandRequestOnce performs a single attempt of a command webhook
+// request, returning the HTTP status code (0 if the request never reached
+// the server) alongside any error so the retry loop in DoCommandRequest
+// can tell a timeout/connection failure apart from a bad response body.
+func (a *App) doCommandRequestOnce(cmd *model.Command, p url.Values, timeout time.Duration) (*model.CommandResponse, int, error) {
+	req, err := a.executeCommandRequest(cmd, p)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	resp, err := a.HTTPService().MakeClient(false).Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, resp.StatusCode, fmt.Errorf("command webhook returned status %d", resp.StatusCode)
+	}
+
+	commandResponse, err := model.CommandResponseFromJson(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+
+	return commandResponse, resp.StatusCode, nil
+}
+
+// RegenCommandSigningSecret issues a new HMAC signing secret for the
+// command commandId, overwriting any previous one. Like RegenCommandToken,
+// the returned secret is the only time the caller can retrieve it; from
+// then on Command.Sanitize strips it before the command is ever returned
+// to a client again.
+func (a *App) RegenCommandSigningSecret(commandId string) (*model.Command, *model.AppError) {
+	cmd, err := a.GetCommand(commandId)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *cmd
+	updated.SigningSecret = model.NewId()
+	updated.SigningAlgorithm = model.CommandSigningAlgorithmHMACSHA256
+	updated.PreUpdate()
+
+	return a.UpdateCommand(cmd, &updated)
+}
+
+// stampCommandIdOnActions records cmd.Id on every PostAction.Integration in
+// response.Attachments, so that when one of those actions is later clicked
+// (see App.DoPostAction), the handler can look the owning command back up
+// to sign the callback request with its SigningSecret.
+func stampCommandIdOnActions(cmd *model.Command, response *model.CommandResponse) {
+	for _, attachment := range response.Attachments {
+		for _, action := range attachment.Actions {
+			if action.Integration != nil {
+				action.Integration.CommandId = cmd.Id
+			}
+		}
+	}
+}
+
+// CreateCommandResponseToken mints a CommandResponseToken scoped to cmd
+// and the channel/thread args was invoked from, persists it, and returns
+// the ResponseURL that should be embedded in the outgoing payload so the
+// integration can reply later instead of within the request's timeout.
+func (a *App) CreateCommandResponseToken(cmd *model.Command, args *model.CommandArgs) (string, *model.AppError) {
+	token := &model.CommandResponseToken{
+		CommandId: cmd.Id,
+		ChannelId: args.ChannelId,
+		RootId:    args.RootId,
+		UserId:    args.UserId,
+	}
+	token.PreSave()
+
+	if err := token.IsValid(); err != nil {
+		return "", err
+	}
+
+	if err := a.Srv().Store.Command().SaveResponseToken(token); err != nil {
+		return "", model.NewAppError("CreateCommandResponseToken", "app.command.create_command_response_token.save.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return args.SiteURL + "/api/v4/commands/responses/" + token.Token, nil
+}
+
+// DeliverCommandResponse redeems a response URL token: it rejects expired,
+// exhausted, or rate-limited tokens, otherwise applies response to the
+// channel/thread the originating command ran in and increments the
+// token's delivery count. A plain response posts a new message, same as
+// the synchronous execute response; ReplaceOriginal edits the post left
+// by this token's previous delivery in place, and DeleteOriginal removes
+// it instead of posting anything. The token remembers which post it last
+// left behind (LastPostId) so a later delivery can replace or delete it.
+//
+// The exhaustion and rate-limit checks below are deliberately re-checked
+// twice: once here, to fail fast without doing the post mutation below at
+// all, and again inside Store.Command().IncrementResponseTokenDelivery,
+// which is the one that actually matters -- it re-validates and bumps
+// DeliveryCount/LastDeliveryAt in a single atomic store operation, so two
+// concurrent deliveries of the same token can't both pass a read here and
+// then both write, exceeding CommandResponseTokenMaxDeliveries.
+func (a *App) DeliverCommandResponse(tokenValue string, response *model.CommandResponse) *model.AppError {
+	token, err := a.Srv().Store.Command().GetResponseToken(tokenValue)
+	if err != nil {
+		return model.NewAppError("DeliverCommandResponse", "app.command.deliver_command_response.not_found.app_error", nil, err.Error(), http.StatusNotFound)
+	}
+
+	if token.IsExpired() {
+		return model.NewAppError("DeliverCommandResponse", "app.command.deliver_command_response.expired.app_error", nil, "", http.StatusUnauthorized)
+	}
+
+	if !token.HasDeliveriesRemaining() {
+		return model.NewAppError("DeliverCommandResponse", "app.command.deliver_command_response.exhausted.app_error", nil, "", http.StatusUnauthorized)
+	}
+
+	if !token.HasRateLimitElapsed() {
+		return model.NewAppError("DeliverCommandResponse", "app.command.deliver_command_response.rate_limited.app_error", nil, "", http.StatusTooManyRequests)
+	}
+
+	token, err = a.Srv().Store.Command().IncrementResponseTokenDelivery(tokenValue)
+	if err != nil {
+		return model.NewAppError("DeliverCommandResponse", "app.command.deliver_command_response.exhausted.app_error", nil, err.Error(), http.StatusUnauthorized)
+	}
+
+	switch {
+	case response.DeleteOriginal:
+		if token.LastPostId == "" {
+			return model.NewAppError("DeliverCommandResponse", "app.command.deliver_command_response.no_post_to_delete.app_error", nil, "", http.StatusBadRequest)
+		}
+
+		if _, err := a.DeletePost(token.LastPostId, token.UserId); err != nil {
+			return err
+		}
+
+		token.LastPostId = ""
+
+	case response.ReplaceOriginal && token.LastPostId != "":
+		post, err := a.GetSinglePost(token.LastPostId, false)
+		if err != nil {
+			return err
+		}
+
+		post.Message = response.Text
+		post.Props = response.Props
+		if len(response.Attachments) > 0 {
+			if post.Props == nil {
+				post.Props = make(map[string]interface{})
+			}
+			post.Props["attachments"] = response.Attachments
+		}
+
+		updated, err := a.UpdatePost(post, false)
+		if err != nil {
+			return err
+		}
+
+		token.LastPostId = updated.Id
+
+	default:
+		args := &model.CommandArgs{
+			ChannelId: token.ChannelId,
+			RootId:    token.RootId,
+			UserId:    token.UserId,
+		}
+
+		post, err := a.HandleCommandResponse(args, response)
+		if err != nil {
+			return err
+		}
+
+		if post != nil {
+			token.LastPostId = post.Id
+		}
+	}
+
+	if err := a.Srv().Store.Command().SaveResponseToken(token); err != nil {
+		return model.NewAppError("DeliverCommandResponse", "app.command.deliver_command_response.update.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// commandBreakerFailureThreshold is the number of consecutive failed
+// attempts (after retries are exhausted) that trips a command's breaker
+// open. commandBreakerCooldown is how long it stays open before requests
+// are allowed through again as trials.
+const (
+	commandBreakerFailureThreshold = 3
+	commandBreakerCooldown         = 30 * time.Second
+)
+
+type commandBreaker struct {
+	mu                  sync.Mutex
+	state               model.CommandBreakerState
+	consecutiveFailures int
+	lastError           string
+	openedAt            time.Time
+}
+
+// commandBreakers holds one breaker per command, created lazily. Breaker
+// state is process-local and intentionally not persisted: a server restart
+// resetting every breaker to closed is the right default behavior.
+var commandBreakers sync.Map // map[string]*commandBreaker
+
+func getCommandBreaker(commandId string) *commandBreaker {
+	v, _ := commandBreakers.LoadOrStore(commandId, &commandBreaker{state: model.CommandBreakerStateClosed})
+	return v.(*commandBreaker)
+}
+
+// allow reports whether a request should be attempted. An open breaker
+// rejects requests until the cool-down window has elapsed; after that it
+// lets every request through as a trial without changing state, until
+// recordSuccess closes it or recordFailure restarts the window.
+func (b *commandBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != model.CommandBreakerStateOpen {
+		return true
+	}
+
+	if time.Since(b.openedAt) < commandBreakerCooldown {
+		return false
+	}
+
+	return true
+}
+
+func (b *commandBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = model.CommandBreakerStateClosed
+	b.consecutiveFailures = 0
+	b.lastError = ""
+}
+
+func (b *commandBreaker) recordFailure(errMsg string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFailures++
+	b.lastError = errMsg
+
+	if b.consecutiveFailures >= commandBreakerFailureThreshold {
+		b.state = model.CommandBreakerStateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *commandBreaker) status(commandId string) *model.CommandBreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return &model.CommandBreakerStatus{
+		CommandId:           commandId,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+		LastError:           b.lastError,
+	}
+}
+
+// GetCommandBreakerStatus returns the current circuit breaker state for
+// cmd, for display on GET /commands/{command_id}/health. A command that
+// has never been executed reports a closed breaker with zero failures.
+func (a *App) GetCommandBreakerStatus(cmd *model.Command) *model.CommandBreakerStatus {
+	return getCommandBreaker(cmd.Id).status(cmd.Id)
+}
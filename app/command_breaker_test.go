@@ -0,0 +1,69 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+func TestCommandBreakerAllowsWhileClosed(t *testing.T) {
+	b := &commandBreaker{state: model.CommandBreakerStateClosed}
+	require.True(t, b.allow())
+}
+
+func TestCommandBreakerTripsOpenAfterConsecutiveFailures(t *testing.T) {
+	b := &commandBreaker{state: model.CommandBreakerStateClosed}
+
+	for i := 0; i < commandBreakerFailureThreshold-1; i++ {
+		b.recordFailure("boom")
+		require.True(t, b.allow(), "breaker should stay closed before the threshold is reached")
+	}
+
+	b.recordFailure("boom")
+	require.False(t, b.allow())
+
+	status := b.status("cmd1")
+	require.Equal(t, model.CommandBreakerStateOpen, status.State)
+	require.Equal(t, commandBreakerFailureThreshold, status.ConsecutiveFailures)
+	require.Equal(t, "boom", status.LastError)
+}
+
+func TestCommandBreakerClosesAfterCooldown(t *testing.T) {
+	b := &commandBreaker{state: model.CommandBreakerStateClosed}
+
+	for i := 0; i < commandBreakerFailureThreshold; i++ {
+		b.recordFailure("boom")
+	}
+	require.False(t, b.allow())
+
+	// Simulate the cool-down window having elapsed.
+	b.openedAt = time.Now().Add(-commandBreakerCooldown - time.Second)
+	require.True(t, b.allow())
+}
+
+func TestCommandBreakerRecordSuccessResetsFailures(t *testing.T) {
+	b := &commandBreaker{state: model.CommandBreakerStateClosed}
+	b.recordFailure("boom")
+	b.recordFailure("boom")
+	b.recordSuccess()
+
+	status := b.status("cmd1")
+	require.Equal(t, model.CommandBreakerStateClosed, status.State)
+	require.Equal(t, 0, status.ConsecutiveFailures)
+	require.Empty(t, status.LastError)
+}
+
+func TestGetCommandBreakerStatusDefaultsToClosed(t *testing.T) {
+	a := &App{}
+	cmd := &model.Command{Id: model.NewId()}
+
+	status := a.GetCommandBreakerStatus(cmd)
+	require.Equal(t, model.CommandBreakerStateClosed, status.State)
+	require.Equal(t, 0, status.ConsecutiveFailures)
+}
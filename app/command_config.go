@@ -0,0 +1,45 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// commandTimeoutCeilingSeconds, commandMaxRetriesCeiling, and
+// commandRetryBackoffCeilingMs return the ceiling DoCommandRequest,
+// CreateCommand, and UpdateCommand enforce for each of
+// Command.TimeoutSeconds/MaxRetries/RetryBackoffMs. These return the
+// package-wide hard maximums unconditionally -- an admin-configurable,
+// stricter ceiling would need a ServiceSettings field this tree's
+// model.Config doesn't define, so there's nothing to read yet. They stay
+// as methods (rather than being inlined at each call site) so that field
+// can be wired in later without touching any caller.
+func (a *App) commandTimeoutCeilingSeconds() int {
+	return model.CommandMaxTimeoutSeconds
+}
+
+func (a *App) commandMaxRetriesCeiling() int {
+	return model.CommandMaxRetries
+}
+
+func (a *App) commandRetryBackoffCeilingMs() int {
+	return model.CommandMaxRetryBackoffMs
+}
+
+// EnsureCommandTimingWithinConfiguredLimits re-validates cmd's
+// TimeoutSeconds, MaxRetries, and RetryBackoffMs against the same
+// ceilings DoCommandRequest enforces at send time, on top of the hard
+// maximums model.Command.IsValid already checked. CreateCommand and
+// UpdateCommand both call this before persisting, so a command can't be
+// saved with timing values DoCommandRequest would silently clamp down
+// anyway.
+func (a *App) EnsureCommandTimingWithinConfiguredLimits(cmd *model.Command) *model.AppError {
+	if err := cmd.IsValidWithLimits(a.commandTimeoutCeilingSeconds(), a.commandMaxRetriesCeiling(), a.commandRetryBackoffCeilingMs()); err != nil {
+		return model.NewAppError("EnsureCommandTimingWithinConfiguredLimits", "api.command.execute_command.timing_exceeds_configured_limit.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+func TestCommandTimingCeilingsMatchHardMaximums(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	require.Equal(t, model.CommandMaxTimeoutSeconds, th.App.commandTimeoutCeilingSeconds())
+	require.Equal(t, model.CommandMaxRetries, th.App.commandMaxRetriesCeiling())
+	require.Equal(t, model.CommandMaxRetryBackoffMs, th.App.commandRetryBackoffCeilingMs())
+}
+
+func TestEnsureCommandTimingWithinConfiguredLimitsRejectsTimeoutAboveHardCeiling(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	cmd := &model.Command{TimeoutSeconds: model.CommandMaxTimeoutSeconds + 1, RetryBackoffMs: model.CommandDefaultRetryBackoffMs}
+	require.NotNil(t, th.App.EnsureCommandTimingWithinConfiguredLimits(cmd))
+
+	cmd.TimeoutSeconds = model.CommandMaxTimeoutSeconds
+	require.Nil(t, th.App.EnsureCommandTimingWithinConfiguredLimits(cmd))
+}
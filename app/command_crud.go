@@ -0,0 +1,235 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// GetCommand returns the command identified by commandId.
+func (a *App) GetCommand(commandId string) (*model.Command, *model.AppError) {
+	if !model.IsValidId(commandId) {
+		return nil, model.NewAppError("GetCommand", "app.command.get_command.missing.app_error", nil, "command_id="+commandId, http.StatusNotFound)
+	}
+
+	cmd, err := a.Srv().Store.Command().Get(commandId)
+	if err != nil {
+		return nil, model.NewAppError("GetCommand", "app.command.get_command.missing.app_error", nil, err.Error(), http.StatusNotFound)
+	}
+
+	return cmd, nil
+}
+
+// CreateCommand registers a new slash command, enforcing the same trigger
+// uniqueness (ValidateCommandTriggerUnique) and admin-configured timing
+// ceilings (EnsureCommandTimingWithinConfiguredLimits) that UpdateCommand
+// re-checks on every later edit. The caller is responsible for checking
+// EnsureCommandCreatePermission first -- this only validates and persists.
+func (a *App) CreateCommand(cmd *model.Command) (*model.Command, *model.AppError) {
+	if !*a.Config().ServiceSettings.EnableCommands {
+		return nil, model.NewAppError("CreateCommand", "api.command.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	cmd.Trigger = strings.ToLower(cmd.Trigger)
+	cmd.PreSave()
+
+	if err := cmd.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if err := a.ValidateCommandTriggerUnique(cmd); err != nil {
+		return nil, err
+	}
+
+	if err := a.EnsureCommandTimingWithinConfiguredLimits(cmd); err != nil {
+		return nil, err
+	}
+
+	if err := a.Srv().Store.Command().Save(cmd); err != nil {
+		return nil, model.NewAppError("CreateCommand", "app.command.create_command.save.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return cmd, nil
+}
+
+// UpdateCommand replaces oldCmd's mutable fields with updatedCmd's,
+// re-running the same trigger-uniqueness and timing-ceiling checks
+// CreateCommand applies. Fields a client can't change directly -- Id,
+// Token, CreatorId, CreateAt -- are always carried over from oldCmd
+// regardless of what updatedCmd supplies.
+func (a *App) UpdateCommand(oldCmd, updatedCmd *model.Command) (*model.Command, *model.AppError) {
+	updatedCmd.Id = oldCmd.Id
+	updatedCmd.Token = oldCmd.Token
+	updatedCmd.CreatorId = oldCmd.CreatorId
+	updatedCmd.CreateAt = oldCmd.CreateAt
+	updatedCmd.Trigger = strings.ToLower(updatedCmd.Trigger)
+	updatedCmd.PreUpdate()
+
+	if err := updatedCmd.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if err := a.ValidateCommandTriggerUnique(updatedCmd); err != nil {
+		return nil, err
+	}
+
+	if err := a.EnsureCommandTimingWithinConfiguredLimits(updatedCmd); err != nil {
+		return nil, err
+	}
+
+	if err := a.Srv().Store.Command().Update(updatedCmd); err != nil {
+		return nil, model.NewAppError("UpdateCommand", "app.command.update_command.save.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return updatedCmd, nil
+}
+
+// parseCommandTrigger splits a raw "/trigger rest of the message" string
+// into its lowercased trigger and the remaining message, the same split
+// ResolveCommandForTrigger's lookup and the outgoing "text" field both need.
+// It returns "" for both when raw isn't a slash command at all.
+func parseCommandTrigger(raw string) (trigger string, message string) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "/") {
+		return "", ""
+	}
+
+	parts := strings.SplitN(raw[1:], " ", 2)
+	trigger = strings.ToLower(parts[0])
+	if len(parts) > 1 {
+		message = parts[1]
+	}
+	return trigger, message
+}
+
+// ExecuteCommand is the entry point for running the slash command embedded
+// in args.Command: it resolves which command the trigger refers to
+// (ResolveCommandForTrigger), which team it runs against
+// (ResolveCommandTeamId, for a direct or group message channel that
+// doesn't imply one on its own), sends the signed, retrying,
+// circuit-broken webhook request (DoCommandRequest), and applies whatever
+// it replied with to the invoking channel (HandleCommandResponse).
+func (a *App) ExecuteCommand(args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
+	if !*a.Config().ServiceSettings.EnableCommands {
+		return nil, model.NewAppError("ExecuteCommand", "api.command.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	trigger, message := parseCommandTrigger(args.Command)
+	if trigger == "" {
+		return nil, model.NewAppError("ExecuteCommand", "api.command.execute_command.no_trigger.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	channel, err := a.GetChannel(args.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := a.GetChannelMember(args.ChannelId, args.UserId); err != nil {
+		return nil, model.NewAppError("ExecuteCommand", "api.command.execute_command.not_channel_member.app_error", nil, err.Error(), http.StatusForbidden)
+	}
+
+	teamId := channel.TeamId
+	if teamId == "" {
+		teamId = args.TeamId
+	}
+
+	cmd, err := a.ResolveCommandForTrigger(teamId, trigger)
+	if err != nil && teamId == "" {
+		// No team implied or supplied: look for a team-scoped command on
+		// any team the invoking user is on, and let ResolveCommandTeamId
+		// pick the team it runs against.
+		if userTeams, teamsErr := a.GetTeamsForUser(args.UserId); teamsErr == nil {
+			for _, team := range userTeams {
+				if teamCmd, cmdErr := a.Srv().Store.Command().GetByTeamAndTrigger(team.Id, trigger); cmdErr == nil && teamCmd != nil {
+					cmd, err = teamCmd, nil
+					break
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedTeamId, err := a.ResolveCommandTeamId(cmd, args.UserId, teamId)
+	if err != nil {
+		return nil, err
+	}
+	args.TeamId = resolvedTeamId
+
+	p := url.Values{}
+	p.Set("token", cmd.Token)
+	p.Set("team_id", resolvedTeamId)
+	p.Set("channel_id", args.ChannelId)
+	p.Set("channel_name", channel.Name)
+	p.Set("user_id", args.UserId)
+	p.Set("command", "/"+trigger)
+	p.Set("text", message)
+	p.Set("trigger_id", model.NewId())
+
+	if user, userErr := a.GetUser(args.UserId); userErr == nil {
+		p.Set("user_name", user.Username)
+	}
+
+	if resolvedTeamId != "" {
+		if team, teamErr := a.GetTeam(resolvedTeamId); teamErr == nil {
+			p.Set("team_domain", team.Name)
+		}
+	}
+
+	if responseURL, tokenErr := a.CreateCommandResponseToken(cmd, args); tokenErr == nil {
+		p.Set("response_url", responseURL)
+	}
+
+	response, err := a.DoCommandRequest(cmd, p)
+	if err != nil {
+		return nil, err
+	}
+
+	response.TriggerId = p.Get("trigger_id")
+
+	if _, err := a.HandleCommandResponse(args, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// HandleCommandResponse applies a CommandResponse to the channel/thread
+// args was invoked from: an ephemeral response is only ever shown to the
+// invoking user, everything else is posted as a message visible to the
+// whole channel. It's shared by ExecuteCommand's synchronous path and
+// DeliverCommandResponse's default (non-replace/delete) case, so a
+// deferred reply renders exactly the way a synchronous one would.
+func (a *App) HandleCommandResponse(args *model.CommandArgs, response *model.CommandResponse) (*model.Post, *model.AppError) {
+	if response == nil || (response.Text == "" && len(response.Attachments) == 0) {
+		return nil, nil
+	}
+
+	post := &model.Post{
+		ChannelId: args.ChannelId,
+		RootId:    args.RootId,
+		UserId:    args.UserId,
+		Message:   response.Text,
+		Type:      response.Type,
+		Props:     response.Props,
+	}
+
+	if len(response.Attachments) > 0 {
+		if post.Props == nil {
+			post.Props = make(map[string]interface{})
+		}
+		post.Props["attachments"] = response.Attachments
+	}
+
+	if response.ResponseType == model.CommandResponseTypeEphemeral {
+		return a.SendEphemeralPost(args.UserId, post), nil
+	}
+
+	return a.CreatePost(post, false)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+func TestExecuteCommandResolvesTeamAndTriggerBeforeSending(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	var gotTeamDomain string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		gotTeamDomain = r.FormValue("team_domain")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text":"ok","response_type":"in_channel"}`))
+	}))
+	defer ts.Close()
+
+	cmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       ts.URL,
+		Method:    model.CommandMethodPost,
+		Trigger:   "crudtest",
+	}
+	created, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	args := &model.CommandArgs{
+		ChannelId: th.BasicChannel.Id,
+		UserId:    th.BasicUser.Id,
+		Command:   "/crudtest hello",
+	}
+
+	response, appErr := th.App.ExecuteCommand(args)
+	require.Nil(t, appErr)
+	require.Equal(t, "ok", response.Text)
+	require.Equal(t, th.BasicTeam.Name, gotTeamDomain)
+
+	_ = created
+}
+
+func TestExecuteCommandRejectsUnknownTrigger(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	args := &model.CommandArgs{
+		ChannelId: th.BasicChannel.Id,
+		UserId:    th.BasicUser.Id,
+		Command:   "/nosuchtrigger",
+	}
+
+	_, appErr := th.App.ExecuteCommand(args)
+	require.NotNil(t, appErr)
+	require.Equal(t, http.StatusNotFound, appErr.StatusCode)
+}
+
+func TestExecuteCommandRejectsUserNotInChannel(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	outsider := th.CreateUser()
+
+	cmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "crudoutsider",
+	}
+	_, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	args := &model.CommandArgs{
+		ChannelId: th.BasicChannel.Id,
+		UserId:    outsider.Id,
+		Command:   "/crudoutsider",
+	}
+
+	_, appErr := th.App.ExecuteCommand(args)
+	require.NotNil(t, appErr)
+	require.Equal(t, http.StatusForbidden, appErr.StatusCode)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// EnsureCommandCreatePermission checks that session is allowed to create
+// cmd. A team-scoped command requires PermissionManageSlashCommands on its
+// own team, as before; a global command (cmd.TeamId == "") is reachable
+// from every team once created, so creating one requires system-wide
+// PermissionManageSystem instead.
+func (a *App) EnsureCommandCreatePermission(session model.Session, cmd *model.Command) *model.AppError {
+	if cmd.TeamId == "" {
+		if !a.SessionHasPermissionTo(session, model.PermissionManageSystem) {
+			return model.NewAppError("EnsureCommandCreatePermission", "api.command.create_command.permission.app_error", nil, "", http.StatusForbidden)
+		}
+		return nil
+	}
+
+	if !a.SessionHasPermissionToTeam(session, cmd.TeamId, model.PermissionManageSlashCommands) {
+		return model.NewAppError("EnsureCommandCreatePermission", "api.command.create_command.permission.app_error", nil, "", http.StatusForbidden)
+	}
+
+	return nil
+}
+
+// ValidateCommandTriggerUnique checks that cmd's trigger doesn't collide
+// with any other command that could be resolved in the same context.
+// A team-scoped command only needs to avoid colliding within its own
+// team and against the global registry, but a global command is
+// reachable from every team, so its trigger must be unique against every
+// team-scoped command as well as every other global command.
+func (a *App) ValidateCommandTriggerUnique(cmd *model.Command) *model.AppError {
+	conflicts := func(teamId string) *model.AppError {
+		existing, err := a.Srv().Store.Command().GetByTeamAndTrigger(teamId, cmd.Trigger)
+		if err != nil {
+			return nil
+		}
+		if existing != nil && existing.Id != cmd.Id {
+			return model.NewAppError("ValidateCommandTriggerUnique", "api.command.duplicate_trigger.app_error", nil, "", http.StatusBadRequest)
+		}
+		return nil
+	}
+
+	if cmd.TeamId == "" {
+		teams, err := a.Srv().Store.Team().GetAll()
+		if err != nil {
+			return model.NewAppError("ValidateCommandTriggerUnique", "app.command.validate_command_trigger_unique.teams.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		for _, team := range teams {
+			if appErr := conflicts(team.Id); appErr != nil {
+				return appErr
+			}
+		}
+
+		return conflicts("")
+	}
+
+	if appErr := conflicts(cmd.TeamId); appErr != nil {
+		return appErr
+	}
+
+	return conflicts("")
+}
+
+// ResolveCommandForTrigger looks up the command invoked by trigger when
+// executed from a channel on team teamId (teamId may be "" for a direct
+// or group message channel). A team-scoped command shadows a global one
+// with the same trigger; when no team-scoped command matches -- or none
+// applies because teamId is "" -- the global registry (commands with
+// TeamId == "") is checked before giving up.
+func (a *App) ResolveCommandForTrigger(teamId string, trigger string) (*model.Command, *model.AppError) {
+	if teamId != "" {
+		if cmd, err := a.Srv().Store.Command().GetByTeamAndTrigger(teamId, trigger); err == nil && cmd != nil {
+			return cmd, nil
+		}
+	}
+
+	cmd, err := a.Srv().Store.Command().GetByTeamAndTrigger("", trigger)
+	if err != nil || cmd == nil {
+		return nil, model.NewAppError("ResolveCommandForTrigger", "app.command.resolve_command_for_trigger.not_found.app_error", nil, "", http.StatusNotFound)
+	}
+
+	return cmd, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+func TestEnsureCommandCreatePermissionRequiresManageSystemForGlobalCommand(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	regularSession := model.Session{UserId: th.BasicUser.Id, Roles: model.SystemUserRoleId}
+	cmd := &model.Command{CreatorId: th.BasicUser.Id, TeamId: ""}
+
+	err := th.App.EnsureCommandCreatePermission(regularSession, cmd)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, err.StatusCode)
+
+	adminSession := model.Session{UserId: th.SystemAdminUser.Id, Roles: model.SystemAdminRoleId}
+	require.Nil(t, th.App.EnsureCommandCreatePermission(adminSession, cmd))
+}
+
+func TestValidateCommandTriggerUniqueRejectsGlobalCollisionWithTeamCommand(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	teamCmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "globalconflict",
+	}
+	_, err := th.App.CreateCommand(teamCmd)
+	require.Nil(t, err)
+
+	globalCmd := &model.Command{CreatorId: th.SystemAdminUser.Id, TeamId: "", Trigger: "globalconflict"}
+
+	appErr := th.App.ValidateCommandTriggerUnique(globalCmd)
+	require.NotNil(t, appErr)
+	require.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+}
+
+func TestValidateCommandTriggerUniqueAllowsDistinctTriggers(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	teamCmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "distincttrigger",
+	}
+	_, err := th.App.CreateCommand(teamCmd)
+	require.Nil(t, err)
+
+	globalCmd := &model.Command{CreatorId: th.SystemAdminUser.Id, TeamId: "", Trigger: "otherglobaltrigger"}
+	require.Nil(t, th.App.ValidateCommandTriggerUnique(globalCmd))
+}
+
+func TestResolveCommandForTriggerFallsBackToGlobalRegistry(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	globalCmd := &model.Command{
+		CreatorId: th.SystemAdminUser.Id,
+		TeamId:    "",
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "globalfallback",
+	}
+	created, err := th.App.CreateCommand(globalCmd)
+	require.Nil(t, err)
+
+	resolved, appErr := th.App.ResolveCommandForTrigger(th.BasicTeam.Id, "globalfallback")
+	require.Nil(t, appErr)
+	require.Equal(t, created.Id, resolved.Id)
+
+	resolved, appErr = th.App.ResolveCommandForTrigger("", "globalfallback")
+	require.Nil(t, appErr)
+	require.Equal(t, created.Id, resolved.Id)
+}
+
+// No test exercises ResolveCommandForTrigger's precedence order (team
+// lookup attempted before the global fallback) directly: a same-trigger
+// collision between a team-scoped and a global command can't arise through normal command
+// creation once ValidateCommandTriggerUnique is enforced there, so there's
+// no valid state in which both can coexist to exercise the shadowing
+// directly.
@@ -0,0 +1,125 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+func TestDeliverCommandResponsePostsNewMessage(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	cmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "followup",
+	}
+	cmd, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	args := &model.CommandArgs{ChannelId: th.BasicChannel.Id, UserId: th.BasicUser.Id}
+	responseURL, err := th.App.CreateCommandResponseToken(cmd, args)
+	require.Nil(t, err)
+
+	token := responseURL[len(responseURL)-26:]
+
+	appErr := th.App.DeliverCommandResponse(token, &model.CommandResponse{Text: "hello"})
+	require.Nil(t, appErr)
+}
+
+func TestDeliverCommandResponseReplaceOriginal(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	cmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "followup2",
+	}
+	cmd, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	args := &model.CommandArgs{ChannelId: th.BasicChannel.Id, UserId: th.BasicUser.Id}
+	responseURL, err := th.App.CreateCommandResponseToken(cmd, args)
+	require.Nil(t, err)
+	token := responseURL[len(responseURL)-26:]
+
+	require.Nil(t, th.App.DeliverCommandResponse(token, &model.CommandResponse{Text: "first"}))
+
+	storedToken, storeErr := th.App.Srv().Store.Command().GetResponseToken(token)
+	require.NoError(t, storeErr)
+	require.NotEmpty(t, storedToken.LastPostId)
+
+	// A second delivery of the same token before
+	// CommandResponseTokenMinDeliveryIntervalMs has elapsed is throttled.
+	time.Sleep(model.CommandResponseTokenMinDeliveryIntervalMs * time.Millisecond)
+
+	appErr := th.App.DeliverCommandResponse(token, &model.CommandResponse{Text: "edited", ReplaceOriginal: true})
+	require.Nil(t, appErr)
+
+	post, appErr := th.App.GetSinglePost(storedToken.LastPostId, false)
+	require.Nil(t, appErr)
+	require.Equal(t, "edited", post.Message)
+}
+
+func TestDeliverCommandResponseRateLimitsRapidRedelivery(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	cmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "followuprate",
+	}
+	cmd, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	args := &model.CommandArgs{ChannelId: th.BasicChannel.Id, UserId: th.BasicUser.Id}
+	responseURL, err := th.App.CreateCommandResponseToken(cmd, args)
+	require.Nil(t, err)
+	token := responseURL[len(responseURL)-26:]
+
+	require.Nil(t, th.App.DeliverCommandResponse(token, &model.CommandResponse{Text: "first"}))
+
+	appErr := th.App.DeliverCommandResponse(token, &model.CommandResponse{Text: "immediately again"})
+	require.NotNil(t, appErr)
+	require.Equal(t, http.StatusTooManyRequests, appErr.StatusCode)
+}
+
+func TestDeliverCommandResponseDeleteOriginalRequiresPriorDelivery(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	cmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "followup3",
+	}
+	cmd, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	args := &model.CommandArgs{ChannelId: th.BasicChannel.Id, UserId: th.BasicUser.Id}
+	responseURL, err := th.App.CreateCommandResponseToken(cmd, args)
+	require.Nil(t, err)
+	token := responseURL[len(responseURL)-26:]
+
+	appErr := th.App.DeliverCommandResponse(token, &model.CommandResponse{DeleteOriginal: true})
+	require.NotNil(t, appErr)
+	require.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+}
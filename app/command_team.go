@@ -0,0 +1,76 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// ResolveCommandTeamId determines which team a slash command execution
+// should run against when the invoking channel doesn't imply one on its
+// own, which is the case for direct and group message channels. The
+// execute-command path calls this once it has determined the channel has
+// no single natural team, instead of requiring the client to guess one.
+//
+// A global command (cmd.TeamId == "") isn't scoped to any team in the
+// first place, so it runs from a direct or group message channel without
+// needing a team resolved for it at all -- this returns "" immediately,
+// regardless of what the client supplied.
+//
+// If the client supplied a team id, both the invoking user and the
+// command's creator must be members of it, or the request is rejected --
+// a client can't use a shared channel to run a command against a team it
+// has no business touching. If the client supplied none, the first team
+// both of them share (ordered by id for a deterministic choice) is used;
+// if they share no team at all, the command can't be run from this
+// channel.
+func (a *App) ResolveCommandTeamId(cmd *model.Command, userId string, suppliedTeamId string) (string, *model.AppError) {
+	if cmd.TeamId == "" {
+		return "", nil
+	}
+
+	if suppliedTeamId != "" {
+		if _, err := a.GetTeamMember(suppliedTeamId, userId); err != nil {
+			return "", model.NewAppError("ResolveCommandTeamId", "api.command.execute_command.no_team_member.app_error", nil, err.Error(), http.StatusForbidden)
+		}
+
+		if _, err := a.GetTeamMember(suppliedTeamId, cmd.CreatorId); err != nil {
+			return "", model.NewAppError("ResolveCommandTeamId", "api.command.execute_command.no_team_member.app_error", nil, err.Error(), http.StatusForbidden)
+		}
+
+		return suppliedTeamId, nil
+	}
+
+	userTeams, err := a.GetTeamsForUser(userId)
+	if err != nil {
+		return "", err
+	}
+
+	creatorTeams, err := a.GetTeamsForUser(cmd.CreatorId)
+	if err != nil {
+		return "", err
+	}
+
+	creatorTeamIds := make(map[string]bool, len(creatorTeams))
+	for _, team := range creatorTeams {
+		creatorTeamIds[team.Id] = true
+	}
+
+	var shared []string
+	for _, team := range userTeams {
+		if creatorTeamIds[team.Id] {
+			shared = append(shared, team.Id)
+		}
+	}
+
+	if len(shared) == 0 {
+		return "", model.NewAppError("ResolveCommandTeamId", "api.command.execute_command.no_shared_team.app_error", nil, "", http.StatusNotFound)
+	}
+
+	sort.Strings(shared)
+	return shared[0], nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+func TestResolveCommandTeamIdWithSuppliedTeam(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	cmd := &model.Command{CreatorId: th.BasicUser.Id, TeamId: th.BasicTeam.Id}
+
+	teamId, err := th.App.ResolveCommandTeamId(cmd, th.BasicUser.Id, th.BasicTeam.Id)
+	require.Nil(t, err)
+	require.Equal(t, th.BasicTeam.Id, teamId)
+}
+
+func TestResolveCommandTeamIdRejectsWhenUserNotOnSuppliedTeam(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	otherTeam := th.CreateTeam()
+	cmd := &model.Command{CreatorId: th.BasicUser.Id, TeamId: otherTeam.Id}
+
+	_, err := th.App.ResolveCommandTeamId(cmd, th.BasicUser.Id, otherTeam.Id)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, err.StatusCode)
+}
+
+func TestResolveCommandTeamIdRejectsWhenCreatorRemovedFromSuppliedTeam(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	creator := th.CreateUser()
+	th.LinkUserToTeam(creator, th.BasicTeam)
+	cmd := &model.Command{CreatorId: creator.Id, TeamId: th.BasicTeam.Id}
+
+	_, err := th.App.RemoveUserFromTeam(th.BasicTeam.Id, creator.Id, "")
+	require.Nil(t, err)
+
+	_, err = th.App.ResolveCommandTeamId(cmd, th.BasicUser.Id, th.BasicTeam.Id)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, err.StatusCode)
+}
+
+func TestResolveCommandTeamIdPicksSharedTeamWhenNoneSupplied(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	cmd := &model.Command{CreatorId: th.BasicUser.Id, TeamId: th.BasicTeam.Id}
+
+	teamId, err := th.App.ResolveCommandTeamId(cmd, th.BasicUser.Id, "")
+	require.Nil(t, err)
+	require.Equal(t, th.BasicTeam.Id, teamId)
+}
+
+func TestResolveCommandTeamIdReturnsEmptyForGlobalCommand(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	cmd := &model.Command{CreatorId: th.BasicUser.Id, TeamId: ""}
+
+	teamId, err := th.App.ResolveCommandTeamId(cmd, th.BasicUser.Id, "")
+	require.Nil(t, err)
+	require.Empty(t, teamId)
+}
+
+func TestResolveCommandTeamIdFailsWhenNoSharedTeam(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	creator := th.CreateUser()
+	creatorOnlyTeam := th.CreateTeam()
+	th.LinkUserToTeam(creator, creatorOnlyTeam)
+
+	cmd := &model.Command{CreatorId: creator.Id, TeamId: creatorOnlyTeam.Id}
+
+	_, err := th.App.ResolveCommandTeamId(cmd, th.BasicUser.Id, "")
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusNotFound, err.StatusCode)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+func TestExecuteCommandRequestFormContentType(t *testing.T) {
+	a := &App{}
+	cmd := &model.Command{URL: "http://example.com/webhook", Method: model.CommandMethodPost}
+
+	p := url.Values{}
+	p.Set("channel_id", "channel1")
+	p.Set("text", "hello world")
+
+	req, err := a.executeCommandRequest(cmd, p)
+	require.NoError(t, err)
+	require.Equal(t, model.CommandContentTypeForm, req.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, p.Encode(), string(body))
+}
+
+func TestExecuteCommandRequestJSONContentType(t *testing.T) {
+	a := &App{}
+	cmd := &model.Command{
+		URL:         "http://example.com/webhook",
+		Method:      model.CommandMethodPost,
+		ContentType: model.CommandContentTypeJSON,
+	}
+
+	p := url.Values{}
+	p.Set("channel_id", "channel1")
+	p.Set("text", "hello world")
+
+	req, err := a.executeCommandRequest(cmd, p)
+	require.NoError(t, err)
+	require.Equal(t, model.CommandContentTypeJSON, req.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"channel_id":"channel1"`)
+	require.Contains(t, string(body), `"args":["hello","world"]`)
+}
+
+func TestExecuteCommandRequestJSONSignsRawBody(t *testing.T) {
+	a := &App{}
+	cmd := &model.Command{
+		URL:           "http://example.com/webhook",
+		Method:        model.CommandMethodPost,
+		ContentType:   model.CommandContentTypeJSON,
+		SigningSecret: "secret",
+	}
+
+	p := url.Values{}
+	p.Set("text", "hi")
+
+	req, err := a.executeCommandRequest(cmd, p)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.NotEmpty(t, req.Header.Get(model.CommandSignatureHeader))
+	require.NotEmpty(t, req.Header.Get(model.CommandSignatureTimestampHeader))
+
+	// The signature must be computed over the exact JSON bytes sent, not
+	// the form-encoded representation of the same values.
+	require.NotEqual(t, p.Encode(), string(body))
+	require.True(t, model.VerifySlashCommandSignature("secret", req.Header, body))
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// GetComplianceReport fetches the compliance job a streaming export page
+// is being requested for, so the handler can validate the cursor's job ID
+// against it before touching the post tables.
+func (a *App) GetComplianceReport(reportId string) (*model.Compliance, *model.AppError) {
+	report, err := a.Srv().Store.Compliance().Get(reportId)
+	if err != nil {
+		return nil, model.NewAppError("GetComplianceReport", "app.compliance.get_compliance_report.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return report, nil
+}
+
+// ComplianceExportPageSize bounds how many posts a single page of the
+// streaming compliance export returns, keeping memory bounded regardless
+// of how large the underlying report is.
+const ComplianceExportPageSize = 1000
+
+// GetComplianceExportPage walks the channels and direct-message queries
+// described by cursor, each bounded to ComplianceExportPageSize posts, and
+// returns the next page along with the cursor to resume from. Once both
+// queries are exhausted cursor.IsComplete() is true and posts is empty.
+func (a *App) GetComplianceExportPage(report *model.Compliance, cursor model.ComplianceExportCursor) ([]*model.CompliancePost, model.ComplianceExportCursor, *model.AppError) {
+	posts, nextCursor, err := a.Srv().Store.Compliance().ExportPage(report, cursor, ComplianceExportPageSize)
+	if err != nil {
+		return nil, cursor, model.NewAppError("GetComplianceExportPage", "app.compliance.get_compliance_export_page.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return posts, nextCursor, nil
+}
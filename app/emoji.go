@@ -0,0 +1,655 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/disintegration/imaging"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+const (
+	MaxEmojiFileSize = 1024 * 1024 // 1MB
+
+	// MaxEmojiWidth and MaxEmojiHeight bound the normalized image the server
+	// stores; anything larger is resized down (preserving aspect ratio)
+	// before being persisted.
+	MaxEmojiWidth  = 128
+	MaxEmojiHeight = 128
+
+	// MaxEmojiOriginalWidth/Height bound what we're willing to decode at
+	// all, to avoid spending CPU resizing absurdly large uploads.
+	MaxEmojiOriginalWidth  = 1024
+	MaxEmojiOriginalHeight = 1024
+)
+
+// CreateEmoji decodes the uploaded image, normalizes it to fit within
+// MaxEmojiWidth/MaxEmojiHeight, and persists both the emoji record and the
+// resulting image bytes. Animated GIFs are resized frame-by-frame and
+// re-quantized so they keep playing correctly once normalized.
+func (a *App) CreateEmoji(userId string, emoji *model.Emoji, multiPartImageData *multipart.Form) (*model.Emoji, *model.AppError) {
+	if !*a.Config().ServiceSettings.EnableCustomEmoji {
+		return nil, model.NewAppError("CreateEmoji", "api.emoji.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if len(emoji.Name) == 0 {
+		return nil, model.NewAppError("CreateEmoji", "api.emoji.create.parse.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if existing, err := a.GetEmojiByName(emoji.Name); err == nil && existing != nil {
+		return nil, model.NewAppError("CreateEmoji", "api.emoji.create.duplicate.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	imageData := multiPartImageData.File["image"]
+	if len(imageData) == 0 {
+		return nil, model.NewAppError("CreateEmoji", "api.emoji.create.no_file.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	normalized, contentType, width, height, frameCount, appErr := normalizeEmojiImage(imageData[0])
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	emoji.CreatorId = userId
+	emoji.Width = width
+	emoji.Height = height
+	emoji.FrameCount = frameCount
+	emoji.PreSave()
+
+	if err := emoji.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if err := a.writeEmojiImage(emoji.Id, normalized); err != nil {
+		return nil, err
+	}
+
+	if err := a.Srv().Store.Emoji().Save(emoji); err != nil {
+		return nil, model.NewAppError("CreateEmoji", "app.emoji.create_emoji.save.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	_ = contentType
+	return emoji, nil
+}
+
+// normalizeEmojiImage decodes fileHeader, rejecting anything that doesn't
+// look like an image, and resizes it down to the configured bounds.
+// Animated formats are handled frame-by-frame: each frame is composited
+// onto a full-size RGBA canvas (since both GIF and APNG frames may only
+// cover part of the logical screen), resized with Lanczos, and
+// re-quantized back down to a palette so the result can be re-encoded as
+// an animated GIF with the original delays and loop count intact - that's
+// also why an Animated PNG normalizes down to a GIF rather than a PNG; see
+// normalizeAnimatedPng and GetEmojiImage.
+func normalizeEmojiImage(fileHeader *multipart.FileHeader) (data []byte, contentType string, width, height, frameCount int, err *model.AppError) {
+	file, openErr := fileHeader.Open()
+	if openErr != nil {
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeEmojiImage", "api.emoji.create.parse.app_error", nil, openErr.Error(), http.StatusBadRequest)
+	}
+	defer file.Close()
+
+	contentType = fileHeader.Header.Get("Content-Type")
+	switch contentType {
+	case "image/gif":
+		return normalizeAnimatedGif(file)
+	case "image/png", "image/jpeg", "image/jpg", "image/bmp", "image/apng", "":
+		raw, readErr := io.ReadAll(file)
+		if readErr != nil {
+			return nil, "", 0, 0, 0, model.NewAppError("normalizeEmojiImage", "api.emoji.create.parse.app_error", nil, readErr.Error(), http.StatusBadRequest)
+		}
+
+		// "image/apng" is occasionally sent explicitly, but most clients
+		// upload an Animated PNG as plain "image/png" (or without a
+		// Content-Type at all), so a PNG-shaped upload is always sniffed
+		// for the acTL chunk that marks it as animated before falling
+		// back to treating it as a static image.
+		if contentType == "image/apng" {
+			return normalizeAnimatedPng(raw)
+		}
+		if chunks, chunkErr := readPNGChunks(raw); chunkErr == nil && isAPNG(chunks) {
+			return normalizeAnimatedPng(raw)
+		}
+
+		return normalizeStaticImage(bytes.NewReader(raw), contentType)
+	default:
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeEmojiImage", "api.emoji.create.unsupported_type.app_error", nil, "content-type="+contentType, http.StatusBadRequest)
+	}
+}
+
+func normalizeStaticImage(file io.Reader, contentType string) ([]byte, string, int, int, int, *model.AppError) {
+	img, format, decodeErr := image.Decode(file)
+	if decodeErr != nil {
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeStaticImage", "api.emoji.create.malformed.app_error", nil, decodeErr.Error(), http.StatusBadRequest)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() > MaxEmojiOriginalWidth || bounds.Dy() > MaxEmojiOriginalHeight {
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeStaticImage", "api.emoji.create.too_large.app_error", nil, "", http.StatusRequestEntityTooLarge)
+	}
+
+	if bounds.Dx() > MaxEmojiWidth || bounds.Dy() > MaxEmojiHeight {
+		img = imaging.Fit(img, MaxEmojiWidth, MaxEmojiHeight, imaging.Lanczos)
+	}
+
+	buf := &bytes.Buffer{}
+	var encodeErr error
+	switch format {
+	case "jpeg":
+		encodeErr = jpeg.Encode(buf, img, &jpeg.Options{Quality: 90})
+	default:
+		format = "png"
+		encodeErr = png.Encode(buf, img)
+	}
+	if encodeErr != nil {
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeStaticImage", "api.emoji.create.encode.app_error", nil, encodeErr.Error(), http.StatusInternalServerError)
+	}
+
+	resultBounds := img.Bounds()
+	return buf.Bytes(), "image/" + format, resultBounds.Dx(), resultBounds.Dy(), 1, nil
+}
+
+func normalizeAnimatedGif(file multipart.File) ([]byte, string, int, int, int, *model.AppError) {
+	g, decodeErr := gif.DecodeAll(file)
+	if decodeErr != nil {
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeAnimatedGif", "api.emoji.create.malformed.app_error", nil, decodeErr.Error(), http.StatusBadRequest)
+	}
+
+	if len(g.Image) == 0 {
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeAnimatedGif", "api.emoji.create.malformed.app_error", nil, "no frames", http.StatusBadRequest)
+	}
+
+	srcWidth, srcHeight := g.Config.Width, g.Config.Height
+	if srcWidth > MaxEmojiOriginalWidth || srcHeight > MaxEmojiOriginalHeight {
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeAnimatedGif", "api.emoji.create.too_large.app_error", nil, "", http.StatusRequestEntityTooLarge)
+	}
+
+	// Composite each frame onto a full canvas the size of the logical
+	// screen before resizing, since individual GIF frames may only cover
+	// the region of the image that changed from the previous frame.
+	canvas := image.NewRGBA(image.Rect(0, 0, srcWidth, srcHeight))
+	frames := make([]compositedFrame, 0, len(g.Image))
+
+	for i, frame := range g.Image {
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		frames = append(frames, compositedFrame{image: snapshotRGBA(canvas), delayCentiseconds: g.Delay[i]})
+
+		if g.Disposal[i] == gif.DisposalBackground {
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+
+	return encodeAnimatedEmoji(frames, g.LoopCount)
+}
+
+// APNG's dispose_op and blend_op values, as defined by the Animated PNG
+// spec (see fcTL in decodeAPNGFrames). Only the ones normalizeAnimatedPng
+// actually acts on are named; APNG's DISPOSE_OP_PREVIOUS (revert to the
+// canvas as it was before this frame) is treated the same as
+// apngDisposeOpNone, matching the level of fidelity normalizeAnimatedGif
+// already has for GIF's analogous DisposalPrevious.
+const (
+	apngDisposeOpNone       = 0
+	apngDisposeOpBackground = 1
+
+	apngBlendOpSource = 0
+)
+
+// compositedFrame is one fully-resolved animation frame: a complete image
+// covering the whole logical canvas, ready to be resized and re-quantized
+// for output. Both GIF and APNG sources are normalized into this common
+// shape before encoding, since both formats only transmit the sub-region
+// of each frame that changed from the last.
+type compositedFrame struct {
+	image             image.Image
+	delayCentiseconds int
+}
+
+// snapshotRGBA copies canvas so appending it to a frame list is safe even
+// though the caller keeps mutating canvas on later iterations.
+func snapshotRGBA(canvas *image.RGBA) *image.RGBA {
+	snapshot := image.NewRGBA(canvas.Bounds())
+	draw.Draw(snapshot, canvas.Bounds(), canvas, canvas.Bounds().Min, draw.Src)
+	return snapshot
+}
+
+// encodeAnimatedEmoji resizes and re-quantizes each of frames down to fit
+// within MaxEmojiWidth/MaxEmojiHeight and encodes them as an animated GIF,
+// which is the one animated format the server stores regardless of the
+// format that was uploaded (see GetEmojiImage).
+func encodeAnimatedEmoji(frames []compositedFrame, loopCount int) ([]byte, string, int, int, int, *model.AppError) {
+	if len(frames) == 0 {
+		return nil, "", 0, 0, 0, model.NewAppError("encodeAnimatedEmoji", "api.emoji.create.malformed.app_error", nil, "no frames", http.StatusBadRequest)
+	}
+
+	bounds := frames[0].image.Bounds()
+	needsResize := bounds.Dx() > MaxEmojiWidth || bounds.Dy() > MaxEmojiHeight
+
+	out := &gif.GIF{LoopCount: loopCount}
+	for _, frame := range frames {
+		img := frame.image
+		if needsResize {
+			img = imaging.Fit(img, MaxEmojiWidth, MaxEmojiHeight, imaging.Lanczos)
+		}
+
+		quantized := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(quantized, img.Bounds(), img, image.Point{})
+
+		out.Image = append(out.Image, quantized)
+		out.Delay = append(out.Delay, frame.delayCentiseconds)
+		out.Disposal = append(out.Disposal, gif.DisposalNone)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := gif.EncodeAll(buf, out); err != nil {
+		return nil, "", 0, 0, 0, model.NewAppError("encodeAnimatedEmoji", "api.emoji.create.encode.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	resultBounds := out.Image[0].Bounds()
+	return buf.Bytes(), "image/gif", resultBounds.Dx(), resultBounds.Dy(), len(out.Image), nil
+}
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// pngChunk is one length-prefixed chunk of a PNG file, with the length and
+// CRC stripped off - writePNGChunk recomputes the CRC when a chunk is
+// written back out.
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// readPNGChunks splits the PNG chunk stream in data into its chunks, in
+// file order, stopping after IEND.
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if len(data) < len(pngSignature) || !bytes.Equal(data[:len(pngSignature)], pngSignature) {
+		return nil, fmt.Errorf("not a png file")
+	}
+
+	var chunks []pngChunk
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		typ := string(data[pos+4 : pos+8])
+		start := pos + 8
+		end := start + length
+		if length < 0 || end+4 > len(data) {
+			return nil, fmt.Errorf("truncated %s chunk", typ)
+		}
+
+		chunkData := make([]byte, length)
+		copy(chunkData, data[start:end])
+		chunks = append(chunks, pngChunk{typ: typ, data: chunkData})
+
+		pos = end + 4
+		if typ == "IEND" {
+			break
+		}
+	}
+
+	return chunks, nil
+}
+
+// isAPNG reports whether chunks contains an acTL chunk before the first
+// IDAT - that's what marks a PNG as an Animated PNG; decoders (including
+// image/png) that don't understand acTL/fcTL/fdAT fall back to treating
+// the file's IDAT as a single static image, which is exactly what
+// normalizeEmojiImage did before this check existed.
+func isAPNG(chunks []pngChunk) bool {
+	for _, c := range chunks {
+		switch c.typ {
+		case "acTL":
+			return true
+		case "IDAT":
+			return false
+		}
+	}
+	return false
+}
+
+// writePNGChunk appends a length-prefixed, CRC-suffixed PNG chunk to buf.
+func writePNGChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var lengthBuf [4]byte
+	binary.BigEndian.PutUint32(lengthBuf[:], uint32(len(data)))
+	buf.Write(lengthBuf[:])
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+
+	buf.WriteString(typ)
+	buf.Write(data)
+
+	var crcBuf [4]byte
+	binary.BigEndian.PutUint32(crcBuf[:], crc.Sum32())
+	buf.Write(crcBuf[:])
+}
+
+// apngDecodedFrame is one raw frame extracted from an Animated PNG, before
+// it has been composited onto the logical canvas: just the image data for
+// its own sub-region, plus the fcTL placement/timing/blending it was
+// published with.
+type apngDecodedFrame struct {
+	image             image.Image
+	xOffset, yOffset  int
+	disposeOp         byte
+	blendOp           byte
+	delayCentiseconds int
+}
+
+// decodeAPNGFrames extracts every animation frame of the Animated PNG in
+// data as a standalone image sized to its own fcTL sub-region, by
+// reassembling a single-frame PNG around each frame's image data (reusing
+// every ancillary chunk from the original file - PLTE, tRNS, gAMA, and so
+// on - except the animation control chunks themselves, which don't belong
+// in a single-frame PNG) and decoding it with the standard library. fdAT
+// chunks carry the same data IDAT would, just prefixed with a 4-byte
+// sequence number that has to be stripped first. An IDAT with no fcTL
+// before it is the non-animated fallback image APNG-unaware viewers show,
+// not part of the animation, so it's skipped here the same way it would be
+// by any other APNG decoder.
+func decodeAPNGFrames(data []byte) (frames []apngDecodedFrame, width, height, loopCount int, err error) {
+	chunks, chunkErr := readPNGChunks(data)
+	if chunkErr != nil {
+		return nil, 0, 0, 0, chunkErr
+	}
+
+	var ihdr pngChunk
+	var passthrough []pngChunk
+
+	type pendingFrame struct {
+		width, height     int
+		xOffset, yOffset  int
+		disposeOp         byte
+		blendOp           byte
+		delayCentiseconds int
+		data              []byte
+	}
+	var pending []*pendingFrame
+	var current *pendingFrame
+
+	for _, c := range chunks {
+		switch c.typ {
+		case "IHDR":
+			ihdr = c
+		case "acTL":
+			if len(c.data) < 8 {
+				return nil, 0, 0, 0, fmt.Errorf("truncated acTL chunk")
+			}
+			loopCount = int(binary.BigEndian.Uint32(c.data[4:8]))
+		case "IEND":
+		case "fcTL":
+			if len(c.data) < 26 {
+				return nil, 0, 0, 0, fmt.Errorf("truncated fcTL chunk")
+			}
+
+			delayNum := binary.BigEndian.Uint16(c.data[20:22])
+			delayDen := binary.BigEndian.Uint16(c.data[22:24])
+			if delayDen == 0 {
+				delayDen = 100
+			}
+
+			current = &pendingFrame{
+				width:             int(binary.BigEndian.Uint32(c.data[4:8])),
+				height:            int(binary.BigEndian.Uint32(c.data[8:12])),
+				xOffset:           int(binary.BigEndian.Uint32(c.data[12:16])),
+				yOffset:           int(binary.BigEndian.Uint32(c.data[16:20])),
+				disposeOp:         c.data[24],
+				blendOp:           c.data[25],
+				delayCentiseconds: int(delayNum) * 100 / int(delayDen),
+			}
+			pending = append(pending, current)
+		case "fdAT":
+			if len(c.data) < 4 {
+				return nil, 0, 0, 0, fmt.Errorf("truncated fdAT chunk")
+			}
+			if current == nil {
+				return nil, 0, 0, 0, fmt.Errorf("fdAT chunk without preceding fcTL")
+			}
+			current.data = append(current.data, c.data[4:]...)
+		case "IDAT":
+			if current != nil {
+				current.data = append(current.data, c.data...)
+			}
+		default:
+			passthrough = append(passthrough, c)
+		}
+	}
+
+	if ihdr.typ != "IHDR" || len(ihdr.data) < 8 {
+		return nil, 0, 0, 0, fmt.Errorf("missing IHDR chunk")
+	}
+	if len(pending) == 0 {
+		return nil, 0, 0, 0, fmt.Errorf("no animation frames found")
+	}
+
+	width = int(binary.BigEndian.Uint32(ihdr.data[0:4]))
+	height = int(binary.BigEndian.Uint32(ihdr.data[4:8]))
+
+	frames = make([]apngDecodedFrame, 0, len(pending))
+	for _, pf := range pending {
+		if len(pf.data) == 0 {
+			return nil, 0, 0, 0, fmt.Errorf("empty frame data")
+		}
+
+		// Every other IHDR field (bit depth, color type, etc.) is shared
+		// with the full image; only the dimensions are specific to this
+		// frame's own fcTL sub-region.
+		frameIHDR := make([]byte, len(ihdr.data))
+		copy(frameIHDR, ihdr.data)
+		binary.BigEndian.PutUint32(frameIHDR[0:4], uint32(pf.width))
+		binary.BigEndian.PutUint32(frameIHDR[4:8], uint32(pf.height))
+
+		buf := &bytes.Buffer{}
+		buf.Write(pngSignature)
+		writePNGChunk(buf, "IHDR", frameIHDR)
+		for _, c := range passthrough {
+			writePNGChunk(buf, c.typ, c.data)
+		}
+		writePNGChunk(buf, "IDAT", pf.data)
+		writePNGChunk(buf, "IEND", nil)
+
+		img, decodeErr := png.Decode(buf)
+		if decodeErr != nil {
+			return nil, 0, 0, 0, decodeErr
+		}
+
+		frames = append(frames, apngDecodedFrame{
+			image:             img,
+			xOffset:           pf.xOffset,
+			yOffset:           pf.yOffset,
+			disposeOp:         pf.disposeOp,
+			blendOp:           pf.blendOp,
+			delayCentiseconds: pf.delayCentiseconds,
+		})
+	}
+
+	return frames, width, height, loopCount, nil
+}
+
+// normalizeAnimatedPng decodes an Animated PNG upload frame by frame,
+// analogous to normalizeAnimatedGif: each frame is composited onto a
+// full-size canvas at the position and with the blend mode its fcTL
+// specified (APNG frames, like GIF frames, may only cover the sub-region
+// that changed) before being handed to encodeAnimatedEmoji. The server has
+// no APNG encoder, so - like any other animated upload - the normalized
+// result is stored as an animated GIF; see GetEmojiImage.
+func normalizeAnimatedPng(data []byte) ([]byte, string, int, int, int, *model.AppError) {
+	apngFrames, width, height, loopCount, decodeErr := decodeAPNGFrames(data)
+	if decodeErr != nil {
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeAnimatedPng", "api.emoji.create.malformed.app_error", nil, decodeErr.Error(), http.StatusBadRequest)
+	}
+
+	if width > MaxEmojiOriginalWidth || height > MaxEmojiOriginalHeight {
+		return nil, "", 0, 0, 0, model.NewAppError("normalizeAnimatedPng", "api.emoji.create.too_large.app_error", nil, "", http.StatusRequestEntityTooLarge)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	frames := make([]compositedFrame, 0, len(apngFrames))
+
+	for _, frame := range apngFrames {
+		region := frame.image.Bounds().Add(image.Pt(frame.xOffset, frame.yOffset))
+
+		op := draw.Over
+		if frame.blendOp == apngBlendOpSource {
+			op = draw.Src
+		}
+		draw.Draw(canvas, region, frame.image, frame.image.Bounds().Min, op)
+
+		frames = append(frames, compositedFrame{image: snapshotRGBA(canvas), delayCentiseconds: frame.delayCentiseconds})
+
+		if frame.disposeOp == apngDisposeOpBackground {
+			draw.Draw(canvas, region, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+
+	return encodeAnimatedEmoji(frames, loopCount)
+}
+
+func (a *App) writeEmojiImage(id string, data []byte) *model.AppError {
+	if err := a.Srv().Store.Emoji().WriteImage(id, data); err != nil {
+		return model.NewAppError("writeEmojiImage", "app.emoji.create_emoji.write_file.app_error", nil, fmt.Sprintf("emoji_id=%s, err=%s", id, err.Error()), http.StatusInternalServerError)
+	}
+	return nil
+}
+
+func (a *App) GetEmoji(emojiId string) (*model.Emoji, *model.AppError) {
+	return a.Srv().Store.Emoji().Get(emojiId)
+}
+
+func (a *App) GetEmojiByName(name string) (*model.Emoji, *model.AppError) {
+	return a.Srv().Store.Emoji().GetByName(name)
+}
+
+func (a *App) GetEmojiList(page, perPage int, sort string) ([]*model.Emoji, *model.AppError) {
+	return a.Srv().Store.Emoji().GetList(page, perPage, sort)
+}
+
+func (a *App) DeleteEmoji(emoji *model.Emoji) *model.AppError {
+	return a.Srv().Store.Emoji().Delete(emoji)
+}
+
+func (a *App) GetEmojiImage(emojiId string) ([]byte, string, *model.AppError) {
+	emoji, err := a.GetEmoji(emojiId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := a.Srv().Store.Emoji().ReadImage(emoji.Id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	imageType := "png"
+	if emoji.FrameCount > 1 {
+		imageType = "gif"
+	}
+
+	return data, imageType, nil
+}
+
+func (a *App) SearchEmoji(name string, prefixOnly bool, limit int) ([]*model.Emoji, *model.AppError) {
+	return a.Srv().Store.Emoji().Search(name, prefixOnly, limit)
+}
+
+// searchEmojiFacetedCandidateMultiplier widens the store-level candidate
+// window SearchEmojiFaceted fetches before applying its own in-memory
+// category/term filters, so a filter narrowing the result set doesn't
+// leave fewer than limit matches on the table even though more exist
+// beyond the store query's own limit.
+const searchEmojiFacetedCandidateMultiplier = 5
+
+// SearchEmojiFaceted extends SearchEmoji with optional category/tag
+// filters and ranks the results so exact-name matches come first, then
+// tag matches, then plain prefix matches, capped at limit. The tag lookup
+// itself is backed by an indexed join table so it doesn't have to scan
+// every emoji, which matters once a deployment has thousands of them. When
+// both term and tags are given, the tag lookup is additionally narrowed to
+// names matching term, so the two facets intersect instead of term only
+// affecting how tag matches are ranked. When category (or term, for the
+// tags lookup) will additionally filter the store's results in memory,
+// the store is asked for a wider candidate window than limit first, so
+// that filter can't silently shrink the final result set below limit
+// while more qualifying emoji exist just beyond the unfiltered window.
+func (a *App) SearchEmojiFaceted(term string, prefixOnly bool, category string, tags []string, limit int) ([]*model.Emoji, *model.AppError) {
+	var candidates []*model.Emoji
+	var err *model.AppError
+
+	fetchLimit := limit
+	if category != "" || (len(tags) > 0 && term != "") {
+		fetchLimit = limit * searchEmojiFacetedCandidateMultiplier
+	}
+
+	if len(tags) > 0 {
+		candidates, err = a.Srv().Store.Emoji().SearchByTags(tags, fetchLimit)
+	} else {
+		candidates, err = a.Srv().Store.Emoji().Search(term, prefixOnly, fetchLimit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := candidates[:0]
+	for _, emoji := range candidates {
+		if category != "" && emoji.Category != category {
+			continue
+		}
+		if len(tags) > 0 && term != "" && !emojiNameMatchesSearchTerm(emoji.Name, term, prefixOnly) {
+			continue
+		}
+		filtered = append(filtered, emoji)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return model.RankEmojiMatch(filtered[i], term) > model.RankEmojiMatch(filtered[j], term)
+	})
+
+	if len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+
+	return filtered, nil
+}
+
+// emojiNameMatchesSearchTerm reports whether name matches term the same
+// way Store.Emoji().Search does: a case-insensitive prefix match, or, when
+// prefixOnly is false, a case-insensitive substring match anywhere in the
+// name.
+func emojiNameMatchesSearchTerm(name, term string, prefixOnly bool) bool {
+	name, term = strings.ToLower(name), strings.ToLower(term)
+	if prefixOnly {
+		return strings.HasPrefix(name, term)
+	}
+	return strings.Contains(name, term)
+}
+
+// UpdateEmojiTags replaces emoji's tag set, persisting it in the
+// emoji_tags join table that SearchEmojiFaceted's tag lookups read from.
+func (a *App) UpdateEmojiTags(emoji *model.Emoji, tags []string) (*model.Emoji, *model.AppError) {
+	emoji.Tags = tags
+	if err := emoji.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if err := a.Srv().Store.Emoji().SaveTags(emoji.Id, tags); err != nil {
+		return nil, model.NewAppError("UpdateEmojiTags", "app.emoji.update_emoji_tags.save.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return emoji, nil
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"mime/multipart"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newMultipartFileHeader(t *testing.T, filename, contentType string, data []byte) *multipart.FileHeader {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreatePart(map[string][]string{
+		"Content-Disposition": {`form-data; name="image"; filename="` + filename + `"`},
+		"Content-Type":        {contentType},
+	})
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	reader := multipart.NewReader(body, writer.Boundary())
+	form, err := reader.ReadForm(int64(len(data)) + 1024)
+	require.NoError(t, err)
+
+	return form.File["image"][0]
+}
+
+func TestNormalizeEmojiImageOversizedPNG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2000, 2000))
+	buf := &bytes.Buffer{}
+	require.NoError(t, png.Encode(buf, img))
+
+	fh := newMultipartFileHeader(t, "big.png", "image/png", buf.Bytes())
+
+	_, _, _, _, _, err := normalizeEmojiImage(fh)
+	require.NotNil(t, err)
+	require.Equal(t, "api.emoji.create.too_large.app_error", err.Id)
+}
+
+func TestNormalizeEmojiImageResizesLargePNG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 512, 256))
+	buf := &bytes.Buffer{}
+	require.NoError(t, png.Encode(buf, img))
+
+	fh := newMultipartFileHeader(t, "emoji.png", "image/png", buf.Bytes())
+
+	data, contentType, width, height, frameCount, err := normalizeEmojiImage(fh)
+	require.Nil(t, err)
+	require.Equal(t, "image/png", contentType)
+	require.Equal(t, 1, frameCount)
+	require.LessOrEqual(t, width, MaxEmojiWidth)
+	require.LessOrEqual(t, height, MaxEmojiHeight)
+	require.NotEmpty(t, data)
+}
+
+func TestNormalizeEmojiImageAnimatedGifWithTransparency(t *testing.T) {
+	palette := []color.Color{color.Transparent, color.Black, color.White}
+	frame1 := image.NewPaletted(image.Rect(0, 0, 64, 64), palette)
+	frame2 := image.NewPaletted(image.Rect(0, 0, 64, 64), palette)
+
+	g := &gif.GIF{
+		Image:     []*image.Paletted{frame1, frame2},
+		Delay:     []int{10, 10},
+		Disposal:  []byte{gif.DisposalBackground, gif.DisposalBackground},
+		LoopCount: 0,
+		Config:    image.Config{Width: 64, Height: 64},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, gif.EncodeAll(buf, g))
+
+	fh := newMultipartFileHeader(t, "anim.gif", "image/gif", buf.Bytes())
+
+	data, contentType, _, _, frameCount, err := normalizeEmojiImage(fh)
+	require.Nil(t, err)
+	require.Equal(t, "image/gif", contentType)
+	require.Equal(t, 2, frameCount)
+	require.NotEmpty(t, data)
+}
+
+// buildTestAPNGFrameIDAT PNG-encodes a solid-color width x height image and
+// returns just its concatenated IDAT data, for splicing into a synthetic
+// APNG's fdAT chunks.
+func buildTestAPNGFrameIDAT(t *testing.T, width, height int, fill color.Color) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: fill}, image.Point{}, draw.Src)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, png.Encode(buf, img))
+
+	chunks, err := readPNGChunks(buf.Bytes())
+	require.NoError(t, err)
+
+	var idat []byte
+	for _, c := range chunks {
+		if c.typ == "IDAT" {
+			idat = append(idat, c.data...)
+		}
+	}
+	require.NotEmpty(t, idat)
+
+	return idat
+}
+
+// buildTestAPNG hand-assembles a minimal two-frame Animated PNG (acTL +
+// fcTL/fdAT pairs, no plain IDAT default image), since the standard
+// library has no APNG encoder to generate test fixtures with.
+func buildTestAPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	frame0 := buildTestAPNGFrameIDAT(t, width, height, color.RGBA{R: 255, A: 255})
+	frame1 := buildTestAPNGFrameIDAT(t, width, height, color.RGBA{B: 255, A: 255})
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:4], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:8], uint32(height))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 2 // color type: truecolor, as png.Encode writes an opaque RGBA image
+
+	acTL := make([]byte, 8)
+	binary.BigEndian.PutUint32(acTL[0:4], 2) // num_frames
+	binary.BigEndian.PutUint32(acTL[4:8], 0) // num_plays: loop forever
+
+	fcTL := func(seq uint32) []byte {
+		data := make([]byte, 26)
+		binary.BigEndian.PutUint32(data[0:4], seq)
+		binary.BigEndian.PutUint32(data[4:8], uint32(width))
+		binary.BigEndian.PutUint32(data[8:12], uint32(height))
+		binary.BigEndian.PutUint16(data[20:22], 10)  // delay_num
+		binary.BigEndian.PutUint16(data[22:24], 100) // delay_den
+		return data
+	}
+
+	fdAT := func(seq uint32, idat []byte) []byte {
+		data := make([]byte, 4+len(idat))
+		binary.BigEndian.PutUint32(data[0:4], seq)
+		copy(data[4:], idat)
+		return data
+	}
+
+	buf := &bytes.Buffer{}
+	buf.Write(pngSignature)
+	writePNGChunk(buf, "IHDR", ihdr)
+	writePNGChunk(buf, "acTL", acTL)
+	writePNGChunk(buf, "fcTL", fcTL(0))
+	writePNGChunk(buf, "fdAT", fdAT(1, frame0))
+	writePNGChunk(buf, "fcTL", fcTL(2))
+	writePNGChunk(buf, "fdAT", fdAT(3, frame1))
+	writePNGChunk(buf, "IEND", nil)
+
+	return buf.Bytes()
+}
+
+func TestIsAPNGDetectsAcTLBeforeFirstIDAT(t *testing.T) {
+	animated := buildTestAPNG(t, 8, 8)
+	chunks, err := readPNGChunks(animated)
+	require.NoError(t, err)
+	require.True(t, isAPNG(chunks))
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	buf := &bytes.Buffer{}
+	require.NoError(t, png.Encode(buf, img))
+	staticChunks, err := readPNGChunks(buf.Bytes())
+	require.NoError(t, err)
+	require.False(t, isAPNG(staticChunks))
+}
+
+func TestNormalizeEmojiImageAnimatedPNG(t *testing.T) {
+	data := buildTestAPNG(t, 32, 32)
+	fh := newMultipartFileHeader(t, "anim.png", "image/png", data)
+
+	normalized, contentType, width, height, frameCount, err := normalizeEmojiImage(fh)
+	require.Nil(t, err)
+	require.Equal(t, "image/gif", contentType)
+	require.Equal(t, 2, frameCount)
+	require.LessOrEqual(t, width, MaxEmojiWidth)
+	require.LessOrEqual(t, height, MaxEmojiHeight)
+	require.NotEmpty(t, normalized)
+}
+
+func TestEmojiNameMatchesSearchTerm(t *testing.T) {
+	require.True(t, emojiNameMatchesSearchTerm("cat_happy", "cat", false))
+	require.True(t, emojiNameMatchesSearchTerm("cat_happy", "cat", true))
+	require.True(t, emojiNameMatchesSearchTerm("happy_cat", "cat", false))
+	require.False(t, emojiNameMatchesSearchTerm("happy_cat", "cat", true))
+	require.False(t, emojiNameMatchesSearchTerm("dog_happy", "cat", false))
+
+	// Case-insensitive, matching Store.Emoji().Search's own semantics.
+	require.True(t, emojiNameMatchesSearchTerm("Cat_Happy", "CAT", false))
+}
+
+func TestNormalizeEmojiImageMalformedUpload(t *testing.T) {
+	fh := newMultipartFileHeader(t, "broken.png", "image/png", []byte("not an image"))
+
+	_, _, _, _, _, err := normalizeEmojiImage(fh)
+	require.NotNil(t, err)
+	require.Equal(t, "api.emoji.create.malformed.app_error", err.Id)
+}
+
+func TestNormalizeEmojiImageRejectsUnsupportedType(t *testing.T) {
+	fh := newMultipartFileHeader(t, "file.txt", "text/plain", []byte("hello"))
+
+	_, _, _, _, _, err := normalizeEmojiImage(fh)
+	require.NotNil(t, err)
+	require.Equal(t, "api.emoji.create.unsupported_type.app_error", err.Id)
+}
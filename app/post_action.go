@@ -0,0 +1,122 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// DoPostAction invokes the PostAction identified by actionId on post
+// postId on behalf of userId: it POSTs a PostActionIntegrationRequest to
+// the action's Integration.URL -- signed, like an outgoing command
+// request, when the command that produced the attachment has a
+// SigningSecret configured -- and applies any Update the integration
+// returns to the source post.
+func (a *App) DoPostAction(postId, actionId, userId string) (*model.PostActionIntegrationResponse, *model.AppError) {
+	post, err := a.GetSinglePost(postId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := a.findPostAction(post, actionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if action.Integration == nil || action.Integration.URL == "" {
+		return nil, model.NewAppError("DoPostAction", "app.post_action.do_post_action.no_integration.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	channel, err := a.GetChannel(post.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	reqPayload := &model.PostActionIntegrationRequest{
+		UserId:    userId,
+		ChannelId: post.ChannelId,
+		TeamId:    channel.TeamId,
+		PostId:    post.Id,
+		ActionId:  action.Id,
+		Type:      action.Type,
+		Context:   action.Integration.Context,
+	}
+	body := reqPayload.ToJson()
+
+	req, reqErr := http.NewRequest(http.MethodPost, action.Integration.URL, bytes.NewReader(body))
+	if reqErr != nil {
+		return nil, model.NewAppError("DoPostAction", "app.post_action.do_post_action.failed.app_error", nil, reqErr.Error(), http.StatusInternalServerError)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if action.Integration.CommandId != "" {
+		if cmd, cmdErr := a.GetCommand(action.Integration.CommandId); cmdErr == nil && cmd.SigningSecret != "" {
+			timestamp := model.GetMillis() / 1000
+			signature := model.ComputeCommandSignature(cmd.SigningSecret, timestamp, body)
+			req.Header.Set(model.CommandSignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+			req.Header.Set(model.CommandSignatureHeader, signature)
+		}
+	}
+
+	resp, doErr := a.HTTPService().MakeClient(false).Do(req)
+	if doErr != nil {
+		return nil, model.NewAppError("DoPostAction", "app.post_action.do_post_action.failed.app_error", nil, doErr.Error(), http.StatusInternalServerError)
+	}
+	defer func() {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	integrationResponse, decodeErr := model.PostActionIntegrationResponseFromJson(resp.Body)
+	if decodeErr != nil {
+		return nil, model.NewAppError("DoPostAction", "app.post_action.do_post_action.bad_response.app_error", nil, decodeErr.Error(), http.StatusInternalServerError)
+	}
+
+	if integrationResponse.Update != nil {
+		integrationResponse.Update.Id = post.Id
+		if _, err := a.UpdatePost(integrationResponse.Update, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return integrationResponse, nil
+}
+
+// findPostAction locates the PostAction actionId among the attachments
+// stored in post.Props["attachments"]. Those attachments are re-decoded
+// from JSON rather than type-asserted directly, since a post round-tripped
+// through the store comes back with its Props values as generic
+// maps/slices rather than the typed structs they were saved as.
+func (a *App) findPostAction(post *model.Post, actionId string) (*model.PostAction, *model.AppError) {
+	raw, ok := post.Props["attachments"]
+	if !ok {
+		return nil, model.NewAppError("findPostAction", "app.post_action.find_post_action.no_action.app_error", nil, "", http.StatusNotFound)
+	}
+
+	b, marshalErr := json.Marshal(raw)
+	if marshalErr != nil {
+		return nil, model.NewAppError("findPostAction", "app.post_action.find_post_action.no_action.app_error", nil, marshalErr.Error(), http.StatusInternalServerError)
+	}
+
+	var attachments []*model.SlackAttachment
+	if unmarshalErr := json.Unmarshal(b, &attachments); unmarshalErr != nil {
+		return nil, model.NewAppError("findPostAction", "app.post_action.find_post_action.no_action.app_error", nil, unmarshalErr.Error(), http.StatusInternalServerError)
+	}
+
+	for _, attachment := range attachments {
+		for _, action := range attachment.Actions {
+			if action.Id == actionId {
+				return action, nil
+			}
+		}
+	}
+
+	return nil, model.NewAppError("findPostAction", "app.post_action.find_post_action.no_action.app_error", nil, "", http.StatusNotFound)
+}
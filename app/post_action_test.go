@@ -0,0 +1,141 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+func attachActionToPost(t *testing.T, th *TestHelper, cmd *model.Command, actionId string, integration *model.PostActionIntegration) string {
+	args := &model.CommandArgs{ChannelId: th.BasicChannel.Id, UserId: th.BasicUser.Id}
+	responseURL, err := th.App.CreateCommandResponseToken(cmd, args)
+	require.Nil(t, err)
+	token := responseURL[len(responseURL)-26:]
+
+	require.Nil(t, th.App.DeliverCommandResponse(token, &model.CommandResponse{Text: "first"}))
+
+	storedToken, storeErr := th.App.Srv().Store.Command().GetResponseToken(token)
+	require.NoError(t, storeErr)
+
+	// Wait out the token's minimum delivery interval before the second delivery.
+	time.Sleep(model.CommandResponseTokenMinDeliveryIntervalMs * time.Millisecond)
+
+	response := &model.CommandResponse{
+		Text:            "updated",
+		ReplaceOriginal: true,
+		Attachments: []*model.SlackAttachment{
+			{
+				Text: "pick one",
+				Actions: []*model.PostAction{
+					{Id: actionId, Type: model.PostActionTypeButton, Integration: integration},
+				},
+			},
+		},
+	}
+	require.Nil(t, th.App.DeliverCommandResponse(token, response))
+
+	return storedToken.LastPostId
+}
+
+func TestDoPostActionAppliesUpdateAndReturnsEphemeralText(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	var gotRequest model.PostActionIntegrationRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotRequest))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&model.PostActionIntegrationResponse{
+			Update:        &model.Post{Message: "updated by action"},
+			EphemeralText: "done",
+		})
+	}))
+	defer ts.Close()
+
+	cmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "actiontest",
+	}
+	cmd, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	postId := attachActionToPost(t, th, cmd, "btn1", &model.PostActionIntegration{URL: ts.URL})
+
+	resp, appErr := th.App.DoPostAction(postId, "btn1", th.BasicUser.Id)
+	require.Nil(t, appErr)
+	require.Equal(t, "done", resp.EphemeralText)
+
+	require.Equal(t, th.BasicUser.Id, gotRequest.UserId)
+	require.Equal(t, th.BasicChannel.Id, gotRequest.ChannelId)
+	require.Equal(t, postId, gotRequest.PostId)
+	require.Equal(t, "btn1", gotRequest.ActionId)
+
+	post, appErr := th.App.GetSinglePost(postId, false)
+	require.Nil(t, appErr)
+	require.Equal(t, "updated by action", post.Message)
+}
+
+func TestDoPostActionSignsRequestWhenCommandHasSigningSecret(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	var gotSignature string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(model.CommandSignatureHeader)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&model.PostActionIntegrationResponse{})
+	}))
+	defer ts.Close()
+
+	cmd := &model.Command{
+		CreatorId:     th.BasicUser.Id,
+		TeamId:        th.BasicTeam.Id,
+		URL:           "http://nowhere.com",
+		Method:        model.CommandMethodPost,
+		Trigger:       "signedactiontest",
+		SigningSecret: model.NewId(),
+	}
+	cmd, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	postId := attachActionToPost(t, th, cmd, "btn2", &model.PostActionIntegration{URL: ts.URL, CommandId: cmd.Id})
+
+	_, appErr := th.App.DoPostAction(postId, "btn2", th.BasicUser.Id)
+	require.Nil(t, appErr)
+	require.NotEmpty(t, gotSignature)
+}
+
+func TestDoPostActionReturnsNotFoundForUnknownAction(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	cmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.CommandMethodPost,
+		Trigger:   "noactiontest",
+	}
+	cmd, err := th.App.CreateCommand(cmd)
+	require.Nil(t, err)
+
+	postId := attachActionToPost(t, th, cmd, "btn3", &model.PostActionIntegration{URL: "http://nowhere.com"})
+
+	_, appErr := th.App.DoPostAction(postId, "does-not-exist", th.BasicUser.Id)
+	require.NotNil(t, appErr)
+	require.Equal(t, http.StatusNotFound, appErr.StatusCode)
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// ExportRoleBundle streams every role, including scheme-scoped roles, as a
+// versioned, signed bundle so it can be version-controlled and later
+// applied to another environment with ImportRoleBundle. The signature is
+// computed with this server's role bundle signing secret (see
+// roleBundleSigningSecret) and only verifies against a bundle signed by
+// the same server (or one sharing the same secret via store replication).
+func (a *App) ExportRoleBundle() (*model.RoleExportBundle, *model.AppError) {
+	roles, err := a.Srv().Store.Role().GetAll()
+	if err != nil {
+		return nil, model.NewAppError("ExportRoleBundle", "app.role.export_role_bundle.get_all.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	secret, appErr := a.roleBundleSigningSecret()
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	signature, sigErr := model.ComputeRoleBundleSignature(secret, roles)
+	if sigErr != nil {
+		return nil, model.NewAppError("ExportRoleBundle", "app.role.export_role_bundle.sign.app_error", nil, sigErr.Error(), http.StatusInternalServerError)
+	}
+
+	return &model.RoleExportBundle{
+		SchemaVersion: model.RoleBundleSchemaVersion,
+		ExportedAt:    model.GetMillis(),
+		Roles:         roles,
+		Signature:     signature,
+	}, nil
+}
+
+// ImportRoleBundle validates a previously exported bundle and applies its
+// permission sets, resolving additions/removals against the same
+// not-allowed-permission blacklist and guest/license gating that patchRole
+// already enforces for a single role (see checkNotAllowedPermissionChanges
+// and EnsureRolePermissionChangeAllowed). The bundle's Signature is
+// verified against this server's role bundle signing secret before
+// anything else, so a bundle edited after export -- or one that never came
+// from ExportRoleBundle at all -- is rejected outright, dry run or not.
+// When dryRun is true, the diff is computed and returned without mutating
+// anything so admins can review it first. Non-dry-run imports are applied
+// atomically: if any role in the bundle fails validation, nothing is
+// persisted.
+func (a *App) ImportRoleBundle(ctx context.Context, bundle *model.RoleExportBundle, dryRun bool) (*model.RoleImportResult, *model.AppError) {
+	if bundle == nil {
+		return nil, model.NewAppError("ImportRoleBundle", "app.role.import_role_bundle.nil_bundle.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if bundle.SchemaVersion != model.RoleBundleSchemaVersion {
+		return nil, model.NewAppError("ImportRoleBundle", "app.role.import_role_bundle.schema_version.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	secret, appErr := a.roleBundleSigningSecret()
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if !model.VerifyRoleBundleSignature(secret, bundle) {
+		return nil, model.NewAppError("ImportRoleBundle", "app.role.import_role_bundle.invalid_signature.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	result := &model.RoleImportResult{}
+
+	plannedUpdates := make([]*model.Role, 0, len(bundle.Roles))
+
+	for _, incoming := range bundle.Roles {
+		oldRole, err := a.GetRoleByName(ctx, incoming.Name)
+		if err != nil {
+			return nil, model.NewAppError("ImportRoleBundle", "app.role.import_role_bundle.unknown_role.app_error", nil, "role="+incoming.Name+", "+err.Error(), http.StatusBadRequest)
+		}
+
+		if appErr := checkNotAllowedPermissionChanges(oldRole, incoming); appErr != nil {
+			return nil, appErr
+		}
+
+		if appErr := a.EnsureRolePermissionChangeAllowed(oldRole); appErr != nil {
+			return nil, appErr
+		}
+
+		diff := model.DiffRolePermissions(oldRole, incoming)
+		result.Diffs = append(result.Diffs, diff)
+
+		updated := oldRole.DeepCopy()
+		updated.Permissions = incoming.Permissions
+		plannedUpdates = append(plannedUpdates, updated)
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if err := a.Srv().Store.Role().SaveMultiple(plannedUpdates); err != nil {
+		return nil, model.NewAppError("ImportRoleBundle", "app.role.import_role_bundle.save.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	result.Applied = true
+	return result, nil
+}
+
+// roleBundleSigningSecret returns the HMAC secret ExportRoleBundle and
+// ImportRoleBundle sign and verify bundles with, generating and
+// persisting one the first time it's needed so every export after the
+// first uses the same secret.
+func (a *App) roleBundleSigningSecret() (string, *model.AppError) {
+	system, err := a.Srv().Store.System().GetByName(model.SystemRoleBundleSigningSecretKey)
+	if err == nil && system != nil && system.Value != "" {
+		return system.Value, nil
+	}
+
+	secret := model.NewId()
+	if saveErr := a.Srv().Store.System().SaveOrUpdate(&model.System{Name: model.SystemRoleBundleSigningSecretKey, Value: secret}); saveErr != nil {
+		return "", model.NewAppError("roleBundleSigningSecret", "app.role.role_bundle_signing_secret.save.app_error", nil, saveErr.Error(), http.StatusInternalServerError)
+	}
+
+	return secret, nil
+}
+
+// EnsureRolePermissionChangeAllowed applies the guest/license gating
+// patchRole enforces before it will accept a permission change to oldRole:
+// changing a guest role's (SystemGuestRoleId/TeamGuestRoleId/
+// ChannelGuestRoleId) permissions at all requires a license, and once one
+// is present, requires the GuestAccountsPermissions feature specifically.
+// Non-guest roles are always allowed through. This is shared by api4's
+// patchRole (a single role) and ImportRoleBundle (every role in a bundle)
+// so neither path can do something the other forbids.
+func (a *App) EnsureRolePermissionChangeAllowed(oldRole *model.Role) *model.AppError {
+	isGuest := oldRole.Name == model.SystemGuestRoleId || oldRole.Name == model.TeamGuestRoleId || oldRole.Name == model.ChannelGuestRoleId
+	if !isGuest {
+		return nil
+	}
+
+	license := a.Srv().License()
+	if license == nil {
+		return model.NewAppError("EnsureRolePermissionChangeAllowed", "api.roles.patch_roles.license.error", nil, "", http.StatusNotImplemented)
+	}
+
+	if !*license.Features.GuestAccountsPermissions {
+		return model.NewAppError("EnsureRolePermissionChangeAllowed", "api.roles.patch_roles.license.error", nil, "", http.StatusNotImplemented)
+	}
+
+	return nil
+}
+
+// checkNotAllowedPermissionChanges applies the same not-allowed-permission
+// blacklist used by patchRole (notAllowedPermissions in api4), so bulk
+// imports can't add or remove a permission a single PatchRole call
+// couldn't. It does not cover the guest/license gating - see
+// EnsureRolePermissionChangeAllowed for that.
+func checkNotAllowedPermissionChanges(oldRole, newRole *model.Role) *model.AppError {
+	diff := model.DiffRolePermissions(oldRole, newRole)
+
+	for _, changed := range append(diff.PermissionsAdded, diff.PermissionsRemoved...) {
+		for _, notAllowed := range notAllowedRoleBundlePermissions {
+			if changed == notAllowed {
+				return model.NewAppError("ImportRoleBundle", "app.role.import_role_bundle.not_allowed_permission.app_error", nil, "permission="+changed, http.StatusNotImplemented)
+			}
+		}
+	}
+
+	return nil
+}
+
+var notAllowedRoleBundlePermissions = []string{
+	model.PermissionSysconsoleWriteUserManagementSystemRoles.Id,
+	model.PermissionSysconsoleReadUserManagementSystemRoles.Id,
+	model.PermissionManageRoles.Id,
+}
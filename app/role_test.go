@@ -0,0 +1,60 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+func TestEnsureRolePermissionChangeAllowedAllowsNonGuestRoleRegardlessOfLicense(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	role := &model.Role{Name: model.SystemUserRoleId}
+	require.Nil(t, th.App.EnsureRolePermissionChangeAllowed(role))
+}
+
+func TestEnsureRolePermissionChangeAllowedRejectsGuestRoleWithoutLicense(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	for _, roleName := range []string{model.SystemGuestRoleId, model.TeamGuestRoleId, model.ChannelGuestRoleId} {
+		role := &model.Role{Name: roleName}
+		appErr := th.App.EnsureRolePermissionChangeAllowed(role)
+		require.NotNil(t, appErr)
+		require.Equal(t, http.StatusNotImplemented, appErr.StatusCode)
+	}
+}
+
+func TestExportRoleBundleIsSignedAndImportRoleBundleAcceptsItBack(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	bundle, err := th.App.ExportRoleBundle()
+	require.Nil(t, err)
+	require.NotEmpty(t, bundle.Signature)
+
+	_, importErr := th.App.ImportRoleBundle(context.Background(), bundle, true)
+	require.Nil(t, importErr)
+}
+
+func TestImportRoleBundleRejectsTamperedSignature(t *testing.T) {
+	th := Setup(t).InitBasic()
+	defer th.TearDown()
+
+	bundle, err := th.App.ExportRoleBundle()
+	require.Nil(t, err)
+
+	bundle.Signature = "tampered"
+
+	_, importErr := th.App.ImportRoleBundle(context.Background(), bundle, true)
+	require.NotNil(t, importErr)
+	require.Equal(t, http.StatusBadRequest, importErr.StatusCode)
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import "encoding/json"
+
+// CreateCommand registers a new slash command. A command with TeamId set
+// creates a command scoped to that team; an empty TeamId creates a global
+// command reachable from every team, which requires manage_system rather
+// than manage_slash_commands.
+func (c *Client4) CreateCommand(cmd *Command) (*Command, *Response) {
+	commandJSON, jsonErr := json.Marshal(cmd)
+	if jsonErr != nil {
+		return nil, nil
+	}
+
+	r, err := c.DoAPIPost("/commands", string(commandJSON))
+	if err != nil {
+		return nil, BuildResponse(r)
+	}
+	defer closeBody(r)
+
+	var created *Command
+	if decodeErr := json.NewDecoder(r.Body).Decode(&created); decodeErr != nil {
+		return nil, BuildResponse(r)
+	}
+
+	return created, BuildResponse(r)
+}
+
+// UpdateCommand applies an in-place edit to an existing command, keyed by
+// cmd.Id.
+func (c *Client4) UpdateCommand(cmd *Command) (*Command, *Response) {
+	commandJSON, jsonErr := json.Marshal(cmd)
+	if jsonErr != nil {
+		return nil, nil
+	}
+
+	r, err := c.DoAPIPut(c.commandRoute(cmd.Id), string(commandJSON))
+	if err != nil {
+		return nil, BuildResponse(r)
+	}
+	defer closeBody(r)
+
+	var updated *Command
+	if decodeErr := json.NewDecoder(r.Body).Decode(&updated); decodeErr != nil {
+		return nil, BuildResponse(r)
+	}
+
+	return updated, BuildResponse(r)
+}
+
+// ExecuteCommand runs the slash command embedded in commandText (e.g.
+// "/weather Boston") against channelId, letting the server resolve which
+// command, team, and (for a deferred response) response URL token apply.
+func (c *Client4) ExecuteCommand(channelId, commandText string) (*CommandResponse, *Response) {
+	return c.ExecuteCommandWithTeam(channelId, "", commandText)
+}
+
+// ExecuteCommandWithTeam is ExecuteCommand with an explicit teamId, for the
+// cases (a direct or group message channel running a team-scoped command)
+// where the channel itself doesn't imply a single team -- see
+// App.ResolveCommandTeamId.
+func (c *Client4) ExecuteCommandWithTeam(channelId, teamId, commandText string) (*CommandResponse, *Response) {
+	args := &CommandArgs{
+		ChannelId: channelId,
+		TeamId:    teamId,
+		Command:   commandText,
+	}
+
+	argsJSON, jsonErr := json.Marshal(args)
+	if jsonErr != nil {
+		return nil, nil
+	}
+
+	r, err := c.DoAPIPost("/commands/execute", string(argsJSON))
+	if err != nil {
+		return nil, BuildResponse(r)
+	}
+	defer closeBody(r)
+
+	response, decodeErr := CommandResponseFromJson(r.Body)
+	if decodeErr != nil {
+		return nil, BuildResponse(r)
+	}
+
+	return response, BuildResponse(r)
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import "encoding/json"
+
+// GetCommandHealth returns the circuit breaker status for the command
+// commandId: whether it's currently closed or open, its consecutive
+// failure count, and its most recent error.
+func (c *Client4) GetCommandHealth(commandId string) (*CommandBreakerStatus, *Response) {
+	r, err := c.DoAPIGet(c.commandRoute(commandId)+"/health", "")
+	if err != nil {
+		return nil, BuildResponse(r)
+	}
+	defer closeBody(r)
+
+	var status CommandBreakerStatus
+	if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+		return nil, BuildResponse(r)
+	}
+
+	return &status, BuildResponse(r)
+}
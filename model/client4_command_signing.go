@@ -0,0 +1,17 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+// RegenCommandSigningSecret issues a new HMAC signing secret for the
+// command commandId and returns it. As with RegenCommandToken, this is
+// the only response that will ever contain the secret in plaintext.
+func (c *Client4) RegenCommandSigningSecret(commandId string) (string, *Response) {
+	r, err := c.DoAPIPut(c.commandRoute(commandId)+"/regen_signing_secret", "")
+	if err != nil {
+		return "", BuildResponse(r)
+	}
+	defer closeBody(r)
+
+	return MapFromJson(r.Body)["signing_secret"], BuildResponse(r)
+}
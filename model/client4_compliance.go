@@ -0,0 +1,71 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+)
+
+// StreamComplianceReport yields pages of a compliance export until both
+// ChannelsQueryCompleted and DirectMessagesQueryCompleted are true,
+// calling onPage once per page with the posts it contains. It threads the
+// opaque cursor the server returns between requests so callers don't need
+// to understand ComplianceExportCursor themselves.
+func (c *Client4) StreamComplianceReport(reportId string, onPage func(posts []*CompliancePost) error) error {
+	cursor := ""
+
+	for {
+		query := url.Values{}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		r, err := c.DoAPIGet(c.complianceReportStreamRoute(reportId)+"?"+query.Encode(), "")
+		if err != nil {
+			return err
+		}
+
+		posts, decodeErr := decodeNDJSONCompliancePosts(r.Body)
+		cursor = r.Header.Get("X-Mattermost-Compliance-Cursor")
+		completed := r.Header.Get("X-Mattermost-Compliance-Completed") == "true"
+		closeBody(r)
+
+		if decodeErr != nil {
+			return decodeErr
+		}
+
+		if err := onPage(posts); err != nil {
+			return err
+		}
+
+		if completed {
+			return nil
+		}
+	}
+}
+
+// decodeNDJSONCompliancePosts reads body as newline-delimited JSON -- one
+// CompliancePost object per line -- matching the "application/x-ndjson"
+// streamComplianceReport writes a page as, rather than a single JSON
+// array.
+func decodeNDJSONCompliancePosts(body io.Reader) ([]*CompliancePost, error) {
+	var posts []*CompliancePost
+
+	decoder := json.NewDecoder(body)
+	for decoder.More() {
+		var post CompliancePost
+		if err := decoder.Decode(&post); err != nil {
+			return nil, err
+		}
+		posts = append(posts, &post)
+	}
+
+	return posts, nil
+}
+
+func (c *Client4) complianceReportStreamRoute(reportId string) string {
+	return c.complianceRoute() + "/reports/" + reportId + "/stream"
+}
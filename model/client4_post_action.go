@@ -0,0 +1,26 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import "encoding/json"
+
+// DoPostAction invokes the PostAction actionId attached to post postId --
+// equivalent to a user clicking the corresponding button or select option
+// -- and returns whatever the action's integration replied with.
+func (c *Client4) DoPostAction(postId, actionId string) (*PostActionIntegrationResponse, *Response) {
+	body, _ := json.Marshal(map[string]string{"post_id": postId})
+
+	r, err := c.DoAPIPost("/commands/actions/"+actionId, string(body))
+	if err != nil {
+		return nil, BuildResponse(r)
+	}
+	defer closeBody(r)
+
+	resp, decodeErr := PostActionIntegrationResponseFromJson(r.Body)
+	if decodeErr != nil {
+		return nil, BuildResponse(r)
+	}
+
+	return resp, BuildResponse(r)
+}
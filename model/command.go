@@ -0,0 +1,319 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	CommandMethodPost = "P"
+	CommandMethodGet  = "G"
+	MinTriggerLength  = 1
+	MaxTriggerLength  = 128
+
+	CommandSigningAlgorithmHMACSHA256 = "hmac-sha256"
+
+	CommandContentTypeForm = "application/x-www-form-urlencoded"
+	CommandContentTypeJSON = "application/json"
+
+	CommandDefaultTimeoutSeconds = 10
+
+	// CommandMaxTimeoutSeconds, CommandMaxRetries, and
+	// CommandMaxRetryBackoffMs are the hard ceilings Command.IsValid
+	// enforces unconditionally. The app layer re-checks the same ceilings
+	// again at create/update time and once more before sending the
+	// request (see App.EnsureCommandTimingWithinConfiguredLimits and
+	// App.DoCommandRequest) rather than inlining these constants at every
+	// call site, so a narrower, admin-configurable ceiling can be wired in
+	// later without touching any of them.
+	CommandMaxTimeoutSeconds = 60
+
+	CommandDefaultRetryBackoffMs = 500
+	CommandMaxRetries            = 5
+	CommandMaxRetryBackoffMs     = 10000
+)
+
+type Command struct {
+	Id               string `json:"id"`
+	Token            string `json:"token"`
+	CreateAt         int64  `json:"create_at"`
+	UpdateAt         int64  `json:"update_at"`
+	DeleteAt         int64  `json:"delete_at"`
+	CreatorId        string `json:"creator_id"`
+	TeamId           string `json:"team_id"`
+	Trigger          string `json:"trigger"`
+	Method           string `json:"method"`
+	Username         string `json:"username"`
+	IconURL          string `json:"icon_url"`
+	AutoComplete     bool   `json:"auto_complete"`
+	AutoCompleteDesc string `json:"auto_complete_desc"`
+	AutoCompleteHint string `json:"auto_complete_hint"`
+	DisplayName      string `json:"display_name"`
+	Description      string `json:"description"`
+	URL              string `json:"url"`
+	PluginId         string `json:"plugin_id"`
+
+	// SigningSecret, when set, causes outgoing requests for this command to
+	// be signed with an HMAC-SHA256 signature instead of relying solely on
+	// Token. SigningAlgorithm is currently always CommandSigningAlgorithmHMACSHA256
+	// when a secret is set, but is stored explicitly to allow future schemes.
+	SigningSecret    string `json:"signing_secret,omitempty"`
+	SigningAlgorithm string `json:"signing_algorithm,omitempty"`
+
+	// ContentType selects how the outgoing request body is encoded.
+	// It defaults to CommandContentTypeForm for backwards compatibility;
+	// setting it to CommandContentTypeJSON sends a structured
+	// CommandPayload as the request body instead of form fields.
+	ContentType string `json:"content_type"`
+
+	// TimeoutSeconds bounds how long the server waits for the command's
+	// webhook to respond before giving up. MaxRetries and RetryBackoffMs
+	// control how many additional attempts are made, and how long to wait
+	// between them, when the webhook times out or returns a server error.
+	TimeoutSeconds int `json:"timeout_seconds"`
+	MaxRetries     int `json:"max_retries"`
+	RetryBackoffMs int `json:"retry_backoff_ms"`
+}
+
+func (o *Command) IsValid() *AppError {
+	if !IsValidId(o.Id) {
+		return NewAppError("Command.IsValid", "model.command.is_valid.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("Command.IsValid", "model.command.is_valid.create_at.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewAppError("Command.IsValid", "model.command.is_valid.update_at.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if !IsValidId(o.CreatorId) {
+		return NewAppError("Command.IsValid", "model.command.is_valid.creator_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	// TeamId is normally required, but an empty TeamId marks a "global"
+	// command: one that isn't scoped to any single team and is reachable
+	// from every team (see App.EnsureCommandCreatePermission and
+	// App.ResolveCommandForTrigger), so it's deliberately exempt here.
+	if o.TeamId != "" && !IsValidId(o.TeamId) {
+		return NewAppError("Command.IsValid", "model.command.is_valid.team_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(o.Trigger) < MinTriggerLength || len(o.Trigger) > MaxTriggerLength {
+		return NewAppError("Command.IsValid", "model.command.is_valid.trigger.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.URL == "" || len(o.URL) > 1024 {
+		return NewAppError("Command.IsValid", "model.command.is_valid.url.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.Method != CommandMethodGet && o.Method != CommandMethodPost {
+		return NewAppError("Command.IsValid", "model.command.is_valid.method.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.ContentType != "" && o.ContentType != CommandContentTypeForm && o.ContentType != CommandContentTypeJSON {
+		return NewAppError("Command.IsValid", "model.command.is_valid.content_type.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if err := o.IsValidWithLimits(CommandMaxTimeoutSeconds, CommandMaxRetries, CommandMaxRetryBackoffMs); err != nil {
+		return err
+	}
+
+	if len(o.DisplayName) > 64 {
+		return NewAppError("Command.IsValid", "model.command.is_valid.display_name.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(o.Description) > 128 {
+		return NewAppError("Command.IsValid", "model.command.is_valid.description.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// IsValidWithLimits checks TimeoutSeconds, MaxRetries, and RetryBackoffMs
+// against the supplied ceilings instead of the package-wide
+// CommandMaxTimeoutSeconds/CommandMaxRetries/CommandMaxRetryBackoffMs hard
+// maximums IsValid enforces. This lets a caller (see
+// App.EnsureCommandTimingWithinConfiguredLimits) apply a ceiling on top of
+// the hard safety limits without those two notions of "maximum" having to
+// be the same value.
+func (o *Command) IsValidWithLimits(maxTimeoutSeconds, maxRetries, maxRetryBackoffMs int) *AppError {
+	if o.TimeoutSeconds < 1 || o.TimeoutSeconds > maxTimeoutSeconds {
+		return NewAppError("Command.IsValid", "model.command.is_valid.timeout_seconds.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.MaxRetries < 0 || o.MaxRetries > maxRetries {
+		return NewAppError("Command.IsValid", "model.command.is_valid.max_retries.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.RetryBackoffMs < 0 || o.RetryBackoffMs > maxRetryBackoffMs {
+		return NewAppError("Command.IsValid", "model.command.is_valid.retry_backoff_ms.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *Command) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.Token == "" {
+		o.Token = NewId()
+	}
+
+	if o.ContentType == "" {
+		o.ContentType = CommandContentTypeForm
+	}
+
+	if o.TimeoutSeconds == 0 {
+		o.TimeoutSeconds = CommandDefaultTimeoutSeconds
+	}
+
+	if o.RetryBackoffMs == 0 {
+		o.RetryBackoffMs = CommandDefaultRetryBackoffMs
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+}
+
+func (o *Command) PreUpdate() {
+	o.UpdateAt = GetMillis()
+}
+
+// Sanitize strips fields that external callers who didn't create the
+// command should never see, such as the bearer token and signing secret
+// used to verify the originating request.
+func (o *Command) Sanitize() {
+	o.Token = ""
+	o.SigningSecret = ""
+}
+
+func CommandFromJson(data io.Reader) *Command {
+	var o *Command
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func CommandListToJson(l []*Command) string {
+	b, _ := json.Marshal(l)
+	return string(b)
+}
+
+func CommandListFromJson(data io.Reader) []*Command {
+	var o []*Command
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+const (
+	CommandResponseTypeInChannel = "in_channel"
+	CommandResponseTypeEphemeral = "ephemeral"
+)
+
+type CommandResponse struct {
+	ResponseType string                 `json:"response_type"`
+	Text         string                 `json:"text"`
+	Username     string                 `json:"username"`
+	ChannelId    string                 `json:"channel_id"`
+	IconURL      string                 `json:"icon_url"`
+	Type         string                 `json:"type"`
+	Props        map[string]interface{} `json:"props"`
+	GotoLocation string                 `json:"goto_location"`
+	TriggerId    string                 `json:"trigger_id,omitempty"`
+
+	// Attachments holds richly formatted, optionally interactive blocks to
+	// render alongside Text. An attachment's Actions, if any, are rendered
+	// as buttons or select menus that POST back to the server when clicked
+	// (see App.DoPostAction), which in turn invokes the action's
+	// Integration.URL and applies whatever it returns to the post.
+	Attachments []*SlackAttachment `json:"attachments,omitempty"`
+
+	// ReplaceOriginal and DeleteOriginal only apply to a CommandResponse
+	// delivered through a response URL token (see App.DeliverCommandResponse):
+	// ReplaceOriginal edits the post left by that token's previous delivery
+	// in place instead of posting a new message, and DeleteOriginal removes
+	// it instead of posting anything at all. They're ignored on the
+	// synchronous response returned directly from command execution, since
+	// there's no earlier post yet to replace or delete.
+	ReplaceOriginal bool `json:"replace_original,omitempty"`
+	DeleteOriginal  bool `json:"delete_original,omitempty"`
+}
+
+func CommandResponseFromJson(data io.Reader) (*CommandResponse, error) {
+	b, err := io.ReadAll(data)
+	if err != nil || len(b) == 0 {
+		return nil, err
+	}
+
+	var o CommandResponse
+	err = json.Unmarshal(b, &o)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Text = strings.TrimSpace(o.Text)
+	return &o, nil
+}
+
+const (
+	CommandSignatureHeader          = "X-Mattermost-Signature"
+	CommandSignatureTimestampHeader = "X-Mattermost-Request-Timestamp"
+	CommandSignatureMaxAge          = 5 * 60 // seconds
+)
+
+// ComputeCommandSignature computes the HMAC-SHA256 signature Mattermost
+// attaches to outgoing slash command requests so the receiver can verify
+// the request actually came from this server and hasn't been replayed.
+// The signed string is "v0:<timestamp>:<body>", matching the Slack-style
+// signing scheme integrators are already familiar with.
+func ComputeCommandSignature(secret string, timestamp int64, body []byte) string {
+	base := "v0:" + strconv.FormatInt(timestamp, 10) + ":" + string(body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(base))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCommandSignature recomputes the expected signature for body and
+// compares it against the one the caller supplied, rejecting signatures
+// for timestamps older than CommandSignatureMaxAge to block replay.
+func VerifyCommandSignature(secret string, timestamp int64, body []byte, signature string) bool {
+	if GetMillis()/1000-timestamp > CommandSignatureMaxAge {
+		return false
+	}
+
+	expected := ComputeCommandSignature(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// VerifySlashCommandSignature is a convenience wrapper around
+// VerifyCommandSignature for integrations receiving a signed slash command
+// request: it pulls the timestamp and signature out of the request headers
+// Mattermost attaches (CommandSignatureTimestampHeader and
+// CommandSignatureHeader) before checking them against body, so a receiver
+// doesn't need to parse those headers itself.
+func VerifySlashCommandSignature(secret string, header http.Header, body []byte) bool {
+	timestamp, parseErr := strconv.ParseInt(header.Get(CommandSignatureTimestampHeader), 10, 64)
+	if parseErr != nil {
+		return false
+	}
+
+	signature := header.Get(CommandSignatureHeader)
+	if signature == "" {
+		return false
+	}
+
+	return VerifyCommandSignature(secret, timestamp, body, signature)
+}
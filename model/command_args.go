@@ -0,0 +1,31 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// CommandArgs carries the request-scoped context ExecuteCommand needs to
+// run a slash command and, later, to apply any follow-up CommandResponse
+// delivered through a response URL or response token: which channel/team
+// it was invoked from, who invoked it, and what thread (if any) it should
+// reply into.
+type CommandArgs struct {
+	ChannelId string `json:"channel_id"`
+	TeamId    string `json:"team_id"`
+	RootId    string `json:"root_id"`
+	ParentId  string `json:"parent_id"`
+	UserId    string `json:"user_id"`
+	TriggerId string `json:"trigger_id"`
+	Command   string `json:"command"`
+	SiteURL   string `json:"-"`
+}
+
+func CommandArgsFromJson(data io.Reader) *CommandArgs {
+	var o *CommandArgs
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import "encoding/json"
+
+// CommandBreakerState describes whether a command's circuit breaker is
+// currently allowing requests through to its webhook (closed) or
+// short-circuiting them during a cool-down window (open).
+type CommandBreakerState string
+
+const (
+	CommandBreakerStateClosed CommandBreakerState = "closed"
+	CommandBreakerStateOpen   CommandBreakerState = "open"
+)
+
+// CommandBreakerStatus is the wire representation of a command's circuit
+// breaker state, returned by GET /commands/{command_id}/health.
+type CommandBreakerStatus struct {
+	CommandId           string               `json:"command_id"`
+	State               CommandBreakerState  `json:"state"`
+	ConsecutiveFailures int                  `json:"consecutive_failures"`
+	LastError           string               `json:"last_error,omitempty"`
+}
+
+func (s *CommandBreakerStatus) ToJson() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"net/url"
+	"strings"
+)
+
+// CommandPayload is the structured body sent to commands configured with
+// ContentType == CommandContentTypeJSON, in place of the legacy
+// form-encoded fields.
+type CommandPayload struct {
+	ChannelId   string   `json:"channel_id"`
+	ChannelName string   `json:"channel_name"`
+	TeamId      string   `json:"team_id"`
+	TeamDomain  string   `json:"team_domain"`
+	UserId      string   `json:"user_id"`
+	UserName    string   `json:"user_name"`
+	Command     string   `json:"command"`
+	Text        string   `json:"text"`
+	ResponseURL string   `json:"response_url"`
+	TriggerId   string   `json:"trigger_id"`
+	Token       string   `json:"token"`
+	Args        []string `json:"args"`
+}
+
+// CommandPayloadFromValues builds a CommandPayload out of the same
+// url.Values the form-encoded path sends, so both content types are
+// populated from a single source of truth and can't drift apart.
+func CommandPayloadFromValues(v url.Values) *CommandPayload {
+	text := v.Get("text")
+
+	return &CommandPayload{
+		ChannelId:   v.Get("channel_id"),
+		ChannelName: v.Get("channel_name"),
+		TeamId:      v.Get("team_id"),
+		TeamDomain:  v.Get("team_domain"),
+		UserId:      v.Get("user_id"),
+		UserName:    v.Get("user_name"),
+		Command:     v.Get("command"),
+		Text:        text,
+		ResponseURL: v.Get("response_url"),
+		TriggerId:   v.Get("trigger_id"),
+		Token:       v.Get("token"),
+		Args:        strings.Fields(text),
+	}
+}
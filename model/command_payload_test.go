@@ -0,0 +1,48 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandPayloadFromValues(t *testing.T) {
+	v := url.Values{}
+	v.Set("channel_id", "channel1")
+	v.Set("channel_name", "town-square")
+	v.Set("team_id", "team1")
+	v.Set("team_domain", "example")
+	v.Set("user_id", "user1")
+	v.Set("user_name", "sample-user")
+	v.Set("command", "/test")
+	v.Set("text", "foo bar")
+	v.Set("response_url", "http://example.com/response")
+	v.Set("trigger_id", "trigger1")
+	v.Set("token", "token1")
+
+	payload := CommandPayloadFromValues(v)
+
+	require.Equal(t, "channel1", payload.ChannelId)
+	require.Equal(t, "town-square", payload.ChannelName)
+	require.Equal(t, "team1", payload.TeamId)
+	require.Equal(t, "example", payload.TeamDomain)
+	require.Equal(t, "user1", payload.UserId)
+	require.Equal(t, "sample-user", payload.UserName)
+	require.Equal(t, "/test", payload.Command)
+	require.Equal(t, "foo bar", payload.Text)
+	require.Equal(t, "http://example.com/response", payload.ResponseURL)
+	require.Equal(t, "trigger1", payload.TriggerId)
+	require.Equal(t, "token1", payload.Token)
+	require.Equal(t, []string{"foo", "bar"}, payload.Args)
+}
+
+func TestCommandPayloadFromValuesEmptyText(t *testing.T) {
+	payload := CommandPayloadFromValues(url.Values{})
+
+	require.Equal(t, "", payload.Text)
+	require.Empty(t, payload.Args)
+}
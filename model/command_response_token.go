@@ -0,0 +1,89 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import "net/http"
+
+const (
+	// CommandResponseTokenTTLSeconds bounds how long a response URL stays
+	// usable after a command executes, so a long-running integration has
+	// time to reply but a leaked token can't be replayed indefinitely.
+	CommandResponseTokenTTLSeconds = 30 * 60
+
+	// CommandResponseTokenMaxDeliveries caps how many follow-up messages a
+	// single response URL can post, preventing a misbehaving integration
+	// from flooding a channel.
+	CommandResponseTokenMaxDeliveries = 5
+
+	// CommandResponseTokenMinDeliveryIntervalMs is the minimum time a
+	// token's response URL must wait between deliveries, throttling an
+	// integration that retries aggressively independent of the absolute
+	// CommandResponseTokenMaxDeliveries cap.
+	CommandResponseTokenMinDeliveryIntervalMs = 2000
+)
+
+// CommandResponseToken is the short-lived, single-command-scoped token
+// minted for the ResponseURL embedded in an outgoing command payload. It
+// lets an integration that can't reply within the request's timeout post
+// one or more CommandResponse payloads afterward.
+type CommandResponseToken struct {
+	Token         string `json:"token"`
+	CommandId     string `json:"command_id"`
+	ChannelId     string `json:"channel_id"`
+	RootId        string `json:"root_id"`
+	UserId        string `json:"user_id"`
+	CreateAt      int64  `json:"create_at"`
+	ExpireAt      int64  `json:"expire_at"`
+	DeliveryCount int    `json:"delivery_count"`
+
+	// LastPostId is the id of the post (if any) left behind by this
+	// token's most recent delivery, so a later delivery with
+	// ReplaceOriginal or DeleteOriginal set knows which post to act on.
+	LastPostId string `json:"last_post_id,omitempty"`
+
+	// LastDeliveryAt is when this token's most recent delivery was
+	// accepted, used to enforce CommandResponseTokenMinDeliveryIntervalMs.
+	// Zero means the token has never been delivered through yet.
+	LastDeliveryAt int64 `json:"last_delivery_at,omitempty"`
+}
+
+func (t *CommandResponseToken) PreSave() {
+	if t.Token == "" {
+		t.Token = NewId()
+	}
+
+	t.CreateAt = GetMillis()
+	t.ExpireAt = t.CreateAt + CommandResponseTokenTTLSeconds*1000
+}
+
+func (t *CommandResponseToken) IsExpired() bool {
+	return GetMillis() > t.ExpireAt
+}
+
+func (t *CommandResponseToken) HasDeliveriesRemaining() bool {
+	return t.DeliveryCount < CommandResponseTokenMaxDeliveries
+}
+
+// HasRateLimitElapsed reports whether enough time has passed since this
+// token's last delivery (CommandResponseTokenMinDeliveryIntervalMs) for
+// another one to be accepted.
+func (t *CommandResponseToken) HasRateLimitElapsed() bool {
+	return t.LastDeliveryAt == 0 || GetMillis()-t.LastDeliveryAt >= CommandResponseTokenMinDeliveryIntervalMs
+}
+
+func (t *CommandResponseToken) IsValid() *AppError {
+	if !IsValidId(t.CommandId) {
+		return NewAppError("CommandResponseToken.IsValid", "model.command_response_token.is_valid.command_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if !IsValidId(t.ChannelId) {
+		return NewAppError("CommandResponseToken.IsValid", "model.command_response_token.is_valid.channel_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if !IsValidId(t.UserId) {
+		return NewAppError("CommandResponseToken.IsValid", "model.command_response_token.is_valid.user_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
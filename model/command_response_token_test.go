@@ -0,0 +1,44 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandResponseTokenPreSave(t *testing.T) {
+	token := &CommandResponseToken{
+		CommandId: NewId(),
+		ChannelId: NewId(),
+		UserId:    NewId(),
+	}
+	token.PreSave()
+
+	require.NotEmpty(t, token.Token)
+	require.False(t, token.IsExpired())
+	require.True(t, token.HasDeliveriesRemaining())
+	require.Nil(t, token.IsValid())
+}
+
+func TestCommandResponseTokenExhausted(t *testing.T) {
+	token := &CommandResponseToken{DeliveryCount: CommandResponseTokenMaxDeliveries}
+	require.False(t, token.HasDeliveriesRemaining())
+}
+
+func TestCommandResponseTokenExpired(t *testing.T) {
+	token := &CommandResponseToken{}
+	token.PreSave()
+	token.ExpireAt = GetMillis() - 1
+
+	require.True(t, token.IsExpired())
+}
+
+func TestCommandResponseTokenLastPostIdDefaultsEmpty(t *testing.T) {
+	token := &CommandResponseToken{}
+	token.PreSave()
+
+	require.Empty(t, token.LastPostId)
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeCommandSignature(t *testing.T) {
+	sig := ComputeCommandSignature("secret", 1577836800, []byte("token=abc&team_domain=test"))
+	require.True(t, len(sig) > len("v0="))
+	require.Equal(t, "v0=", sig[:3])
+
+	// Deterministic for the same inputs.
+	require.Equal(t, sig, ComputeCommandSignature("secret", 1577836800, []byte("token=abc&team_domain=test")))
+
+	// Different body or secret changes the signature.
+	require.NotEqual(t, sig, ComputeCommandSignature("secret", 1577836800, []byte("token=abc&team_domain=other")))
+	require.NotEqual(t, sig, ComputeCommandSignature("other-secret", 1577836800, []byte("token=abc&team_domain=test")))
+}
+
+func TestVerifyCommandSignature(t *testing.T) {
+	now := GetMillis() / 1000
+	body := []byte("token=abc&team_domain=test")
+	sig := ComputeCommandSignature("secret", now, body)
+
+	require.True(t, VerifyCommandSignature("secret", now, body, sig))
+	require.False(t, VerifyCommandSignature("wrong-secret", now, body, sig))
+	require.False(t, VerifyCommandSignature("secret", now, []byte("tampered"), sig))
+
+	// A timestamp older than the allowed window is rejected even with a
+	// correctly computed signature, guarding against replay.
+	old := now - CommandSignatureMaxAge - 60
+	oldSig := ComputeCommandSignature("secret", old, body)
+	require.False(t, VerifyCommandSignature("secret", old, body, oldSig))
+}
+
+func TestVerifySlashCommandSignature(t *testing.T) {
+	now := GetMillis() / 1000
+	body := []byte("token=abc&team_domain=test")
+	sig := ComputeCommandSignature("secret", now, body)
+
+	header := http.Header{}
+	header.Set(CommandSignatureTimestampHeader, strconv.FormatInt(now, 10))
+	header.Set(CommandSignatureHeader, sig)
+
+	require.True(t, VerifySlashCommandSignature("secret", header, body))
+	require.False(t, VerifySlashCommandSignature("wrong-secret", header, body))
+
+	missingHeader := http.Header{}
+	require.False(t, VerifySlashCommandSignature("secret", missingHeader, body))
+
+	old := now - CommandSignatureMaxAge - 60
+	oldHeader := http.Header{}
+	oldHeader.Set(CommandSignatureTimestampHeader, strconv.FormatInt(old, 10))
+	oldHeader.Set(CommandSignatureHeader, ComputeCommandSignature("secret", old, body))
+	require.False(t, VerifySlashCommandSignature("secret", oldHeader, body))
+}
+
+func TestCommandPreSaveDefaultsContentTypeToForm(t *testing.T) {
+	// Existing commands created before ContentType existed decode with an
+	// empty string; PreSave must migrate them to the form default rather
+	// than leaving them with no content type at all.
+	o := &Command{}
+	o.PreSave()
+
+	require.Equal(t, CommandContentTypeForm, o.ContentType)
+}
+
+func TestCommandPreSaveDefaultsTimeoutAndBackoff(t *testing.T) {
+	o := &Command{}
+	o.PreSave()
+
+	require.Equal(t, CommandDefaultTimeoutSeconds, o.TimeoutSeconds)
+	require.Equal(t, CommandDefaultRetryBackoffMs, o.RetryBackoffMs)
+	require.Equal(t, 0, o.MaxRetries)
+
+	o2 := &Command{TimeoutSeconds: 30, RetryBackoffMs: 1000, MaxRetries: 2}
+	o2.PreSave()
+
+	require.Equal(t, 30, o2.TimeoutSeconds)
+	require.Equal(t, 1000, o2.RetryBackoffMs)
+	require.Equal(t, 2, o2.MaxRetries)
+}
+
+func TestCommandIsValidTimeoutAndRetryBounds(t *testing.T) {
+	base := func() *Command {
+		return &Command{
+			Id:             NewId(),
+			CreateAt:       1,
+			UpdateAt:       1,
+			CreatorId:      NewId(),
+			TeamId:         NewId(),
+			Trigger:        "test",
+			URL:            "http://example.com",
+			Method:         CommandMethodPost,
+			TimeoutSeconds: CommandDefaultTimeoutSeconds,
+			RetryBackoffMs: CommandDefaultRetryBackoffMs,
+		}
+	}
+
+	o := base()
+	require.Nil(t, o.IsValid())
+
+	o = base()
+	o.TimeoutSeconds = 0
+	require.NotNil(t, o.IsValid())
+
+	o = base()
+	o.TimeoutSeconds = CommandMaxTimeoutSeconds + 1
+	require.NotNil(t, o.IsValid())
+
+	o = base()
+	o.MaxRetries = -1
+	require.NotNil(t, o.IsValid())
+
+	o = base()
+	o.MaxRetries = CommandMaxRetries + 1
+	require.NotNil(t, o.IsValid())
+
+	o = base()
+	o.RetryBackoffMs = CommandMaxRetryBackoffMs + 1
+	require.NotNil(t, o.IsValid())
+}
+
+func TestCommandIsValidAllowsEmptyTeamIdForGlobalCommand(t *testing.T) {
+	o := &Command{
+		Id:             NewId(),
+		CreateAt:       1,
+		UpdateAt:       1,
+		CreatorId:      NewId(),
+		TeamId:         "",
+		Trigger:        "test",
+		URL:            "http://example.com",
+		Method:         CommandMethodPost,
+		TimeoutSeconds: CommandDefaultTimeoutSeconds,
+		RetryBackoffMs: CommandDefaultRetryBackoffMs,
+	}
+	require.Nil(t, o.IsValid())
+
+	o.TeamId = "not-a-valid-id"
+	require.NotNil(t, o.IsValid())
+}
+
+func TestCommandIsValidContentType(t *testing.T) {
+	o := &Command{
+		Id:             NewId(),
+		CreateAt:       1,
+		UpdateAt:       1,
+		CreatorId:      NewId(),
+		TeamId:         NewId(),
+		Trigger:        "test",
+		URL:            "http://example.com",
+		Method:         CommandMethodPost,
+		TimeoutSeconds: CommandDefaultTimeoutSeconds,
+		RetryBackoffMs: CommandDefaultRetryBackoffMs,
+	}
+
+	o.ContentType = CommandContentTypeForm
+	require.Nil(t, o.IsValid())
+
+	o.ContentType = CommandContentTypeJSON
+	require.Nil(t, o.IsValid())
+
+	o.ContentType = "text/plain"
+	require.NotNil(t, o.IsValid())
+}
@@ -4,6 +4,7 @@
 package model
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -37,6 +38,21 @@ type Compliance struct {
 
 type Compliances []Compliance
 
+// CompliancePost is a single row of a compliance export: one post,
+// flattened with just enough channel/user/team context for an external
+// SIEM or eDiscovery tool to make sense of it without a second lookup.
+type CompliancePost struct {
+	TeamName     string `json:"team_name"`
+	ChannelName  string `json:"channel_name"`
+	ChannelType  string `json:"channel_type"`
+	UserUsername string `json:"user_username"`
+	UserEmail    string `json:"user_email"`
+	PostId       string `json:"post_id"`
+	PostCreateAt int64  `json:"post_create_at"`
+	PostMessage  string `json:"post_message"`
+	PostType     string `json:"post_type"`
+}
+
 // ComplianceExportCursor is used for paginated iteration of posts
 // for compliance export.
 // We need to keep track of the last post ID in addition to the last post
@@ -50,6 +66,59 @@ type ComplianceExportCursor struct {
 	DirectMessagesQueryCompleted        bool
 }
 
+// IsComplete returns true once both the channels and direct messages
+// queries have been fully walked, meaning the stream has no more pages.
+func (c ComplianceExportCursor) IsComplete() bool {
+	return c.ChannelsQueryCompleted && c.DirectMessagesQueryCompleted
+}
+
+// complianceExportCursorToken is the JSON shape that gets base64-encoded
+// into the opaque cursor string handed to API callers. Embedding the
+// report (job) ID lets the stream handler reject a cursor minted for a
+// different report before it ever touches the database.
+type complianceExportCursorToken struct {
+	JobId  string                 `json:"job_id"`
+	Cursor ComplianceExportCursor `json:"cursor"`
+}
+
+// EncodeComplianceExportCursor packages the cursor together with the
+// report's job ID into the opaque, base64-encoded token that is handed
+// back to external SIEM/eDiscovery tools so they can resume a paginated
+// export with GET /compliance/reports/{id}/stream.
+func EncodeComplianceExportCursor(jobId string, cursor ComplianceExportCursor) (string, error) {
+	b, err := json.Marshal(complianceExportCursorToken{JobId: jobId, Cursor: cursor})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// DecodeComplianceExportCursor reverses EncodeComplianceExportCursor and
+// rejects any cursor whose embedded job ID doesn't match jobId, so a
+// cursor minted for one report can't be replayed against another.
+func DecodeComplianceExportCursor(jobId, token string) (*ComplianceExportCursor, error) {
+	if token == "" {
+		return &ComplianceExportCursor{}, nil
+	}
+
+	raw, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded complianceExportCursorToken
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+
+	if decoded.JobId != jobId {
+		return nil, NewAppError("DecodeComplianceExportCursor", "model.compliance.decode_cursor.job_id_mismatch.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return &decoded.Cursor, nil
+}
+
 func (c *Compliance) PreSave() {
 	if c.Id == "" {
 		c.Id = NewId()
@@ -0,0 +1,160 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+const (
+	EmojiNameMaxLength     = 64
+	EmojiSortByName        = "name"
+	EmojiCategoryMaxLength = 64
+	EmojiTagMaxLength      = 64
+	EmojiMaxTags           = 20
+)
+
+type Emoji struct {
+	Id        string `json:"id"`
+	CreatorId string `json:"creator_id"`
+	Name      string `json:"name"`
+	CreateAt  int64  `json:"create_at"`
+	UpdateAt  int64  `json:"update_at"`
+	DeleteAt  int64  `json:"delete_at"`
+
+	// Width, Height, and FrameCount are computed by the server when the
+	// emoji image is processed so clients can reserve the right amount of
+	// space and know up front whether an emoji animates, without having to
+	// decode the image themselves.
+	Width      int `json:"width"`
+	Height     int `json:"height"`
+	FrameCount int `json:"frame_count"`
+
+	// Category and Tags make large custom-emoji libraries navigable:
+	// Category groups emoji for browsing, while Tags back the faceted
+	// search exposed through EmojiSearch.
+	Category string   `json:"category"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+var validEmojiNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_+-]+$`)
+
+func (emoji *Emoji) IsValid() *AppError {
+	if !IsValidId(emoji.Id) {
+		return NewAppError("Emoji.IsValid", "model.emoji.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if emoji.CreateAt == 0 {
+		return NewAppError("Emoji.IsValid", "model.emoji.create_at.app_error", nil, "id="+emoji.Id, http.StatusBadRequest)
+	}
+
+	if emoji.UpdateAt == 0 {
+		return NewAppError("Emoji.IsValid", "model.emoji.update_at.app_error", nil, "id="+emoji.Id, http.StatusBadRequest)
+	}
+
+	if !IsValidId(emoji.CreatorId) {
+		return NewAppError("Emoji.IsValid", "model.emoji.user_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(emoji.Name) == 0 || len(emoji.Name) > EmojiNameMaxLength || !validEmojiNameRegex.MatchString(emoji.Name) {
+		return NewAppError("Emoji.IsValid", "model.emoji.name.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(emoji.Category) > EmojiCategoryMaxLength {
+		return NewAppError("Emoji.IsValid", "model.emoji.category.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(emoji.Tags) > EmojiMaxTags {
+		return NewAppError("Emoji.IsValid", "model.emoji.tags.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	for _, tag := range emoji.Tags {
+		if len(tag) == 0 || len(tag) > EmojiTagMaxLength {
+			return NewAppError("Emoji.IsValid", "model.emoji.tags.app_error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (emoji *Emoji) PreSave() {
+	if emoji.Id == "" {
+		emoji.Id = NewId()
+	}
+
+	emoji.CreateAt = GetMillis()
+	emoji.UpdateAt = emoji.CreateAt
+}
+
+func EmojiFromJson(data io.Reader) *Emoji {
+	var emoji *Emoji
+	json.NewDecoder(data).Decode(&emoji)
+	return emoji
+}
+
+func EmojiListToJson(l []*Emoji) string {
+	b, _ := json.Marshal(l)
+	return string(b)
+}
+
+func EmojiListFromJson(data io.Reader) []*Emoji {
+	var o []*Emoji
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+type EmojiSearch struct {
+	Term       string   `json:"term"`
+	PrefixOnly bool     `json:"prefix_only"`
+	Category   string   `json:"category,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+func EmojiSearchFromJson(data io.Reader) *EmojiSearch {
+	var o *EmojiSearch
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+// EmojiTagsPatch is the body POST /emojis/{emoji_id}/tags accepts to
+// replace an emoji's tag set.
+type EmojiTagsPatch struct {
+	Tags []string `json:"tags"`
+}
+
+func EmojiTagsPatchFromJson(data io.Reader) *EmojiTagsPatch {
+	var o *EmojiTagsPatch
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+// EmojiMatchRank orders autocomplete/search results: an exact name match
+// beats a tag match, which beats a plain prefix match.
+type EmojiMatchRank int
+
+const (
+	EmojiMatchRankPrefix EmojiMatchRank = iota
+	EmojiMatchRankTag
+	EmojiMatchRankExactName
+)
+
+// RankEmojiMatch scores how emoji matched a search/autocomplete term, so
+// callers can sort exact-name matches first, then tag matches, then
+// prefix matches.
+func RankEmojiMatch(emoji *Emoji, term string) EmojiMatchRank {
+	if emoji.Name == term {
+		return EmojiMatchRankExactName
+	}
+
+	for _, tag := range emoji.Tags {
+		if tag == term {
+			return EmojiMatchRankTag
+		}
+	}
+
+	return EmojiMatchRankPrefix
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankEmojiMatch(t *testing.T) {
+	exact := &Emoji{Name: "tada"}
+	require.Equal(t, EmojiMatchRankExactName, RankEmojiMatch(exact, "tada"))
+
+	tagged := &Emoji{Name: "party-parrot", Tags: []string{"tada", "celebrate"}}
+	require.Equal(t, EmojiMatchRankTag, RankEmojiMatch(tagged, "tada"))
+
+	prefix := &Emoji{Name: "tada-but-bigger"}
+	require.Equal(t, EmojiMatchRankPrefix, RankEmojiMatch(prefix, "tada"))
+}
+
+func TestEmojiIsValidTags(t *testing.T) {
+	emoji := &Emoji{
+		Id:        NewId(),
+		CreatorId: NewId(),
+		Name:      "thumbsup",
+		CreateAt:  1,
+		UpdateAt:  1,
+		Tags:      []string{"approve", ""},
+	}
+
+	err := emoji.IsValid()
+	require.NotNil(t, err)
+	require.Equal(t, "model.emoji.tags.app_error", err.Id)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	PostActionTypeButton = "button"
+	PostActionTypeSelect = "select"
+)
+
+type PostActionOptions struct {
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+// PostActionIntegration is the external endpoint invoked when a user
+// interacts with a PostAction -- clicking a button or choosing a select
+// option. CommandId identifies the slash command that produced the
+// attachment this action belongs to; when that command has a
+// SigningSecret configured, the request sent to URL is signed the same
+// way outgoing command requests are (see ComputeCommandSignature).
+type PostActionIntegration struct {
+	URL       string                 `json:"url,omitempty"`
+	Context   map[string]interface{} `json:"context,omitempty"`
+	CommandId string                 `json:"command_id,omitempty"`
+}
+
+// PostAction describes a single interactive element -- a button or select
+// menu -- attached to a SlackAttachment. Id is chosen by whoever builds
+// the attachment (mirroring how Slack attachments key actions by name)
+// and is what a click request references to look the action back up.
+type PostAction struct {
+	Id          string                 `json:"id,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	DataSource  string                 `json:"data_source,omitempty"`
+	Options     []*PostActionOptions   `json:"options,omitempty"`
+	Integration *PostActionIntegration `json:"integration,omitempty"`
+}
+
+// PostActionIntegrationRequest is the payload POSTed to a
+// PostActionIntegration's URL when a user interacts with its action.
+type PostActionIntegrationRequest struct {
+	UserId    string                 `json:"user_id"`
+	ChannelId string                 `json:"channel_id"`
+	TeamId    string                 `json:"team_id"`
+	PostId    string                 `json:"post_id"`
+	ActionId  string                 `json:"action_id"`
+	Type      string                 `json:"type"`
+	Context   map[string]interface{} `json:"context,omitempty"`
+}
+
+func (r *PostActionIntegrationRequest) ToJson() []byte {
+	b, _ := json.Marshal(r)
+	return b
+}
+
+// PostActionIntegrationResponse is what an integration may return after
+// handling an action: Update replaces the source post's content, and
+// EphemeralText, if set, is shown back to the clicking user only.
+type PostActionIntegrationResponse struct {
+	Update        *Post  `json:"update,omitempty"`
+	EphemeralText string `json:"ephemeral_text,omitempty"`
+}
+
+func (r *PostActionIntegrationResponse) ToJson() []byte {
+	b, _ := json.Marshal(r)
+	return b
+}
+
+func PostActionIntegrationResponseFromJson(data io.Reader) (*PostActionIntegrationResponse, error) {
+	var o PostActionIntegrationResponse
+	if err := json.NewDecoder(data).Decode(&o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
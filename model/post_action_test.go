@@ -0,0 +1,36 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostActionIntegrationRequestToJson(t *testing.T) {
+	req := &PostActionIntegrationRequest{
+		UserId:    NewId(),
+		ChannelId: NewId(),
+		TeamId:    NewId(),
+		PostId:    NewId(),
+		ActionId:  "btn1",
+		Type:      PostActionTypeButton,
+	}
+
+	b := req.ToJson()
+	require.Contains(t, string(b), req.UserId)
+	require.Contains(t, string(b), "btn1")
+}
+
+func TestPostActionIntegrationResponseFromJson(t *testing.T) {
+	body := `{"update": {"message": "hi"}, "ephemeral_text": "thanks"}`
+
+	resp, err := PostActionIntegrationResponseFromJson(bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	require.Equal(t, "thanks", resp.EphemeralText)
+	require.NotNil(t, resp.Update)
+	require.Equal(t, "hi", resp.Update.Message)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRolePermissions(t *testing.T) {
+	oldRole := &Role{Name: "test_role", Permissions: []string{"a", "b"}}
+	newRole := &Role{Name: "test_role", Permissions: []string{"b", "c"}}
+
+	diff := DiffRolePermissions(oldRole, newRole)
+
+	require.Equal(t, "test_role", diff.RoleName)
+	require.ElementsMatch(t, []string{"c"}, diff.PermissionsAdded)
+	require.ElementsMatch(t, []string{"a"}, diff.PermissionsRemoved)
+}
+
+func TestDiffRolePermissionsNoChange(t *testing.T) {
+	oldRole := &Role{Name: "test_role", Permissions: []string{"a", "b"}}
+	newRole := &Role{Name: "test_role", Permissions: []string{"b", "a"}}
+
+	diff := DiffRolePermissions(oldRole, newRole)
+
+	require.Empty(t, diff.PermissionsAdded)
+	require.Empty(t, diff.PermissionsRemoved)
+}
+
+func TestVerifyRoleBundleSignatureAcceptsMatchingSignature(t *testing.T) {
+	roles := []*Role{{Name: "test_role", Permissions: []string{"a", "b"}}}
+
+	signature, err := ComputeRoleBundleSignature("secret", roles)
+	require.NoError(t, err)
+
+	bundle := &RoleExportBundle{SchemaVersion: RoleBundleSchemaVersion, Roles: roles, Signature: signature}
+	require.True(t, VerifyRoleBundleSignature("secret", bundle))
+}
+
+func TestVerifyRoleBundleSignatureRejectsTamperedRoles(t *testing.T) {
+	roles := []*Role{{Name: "test_role", Permissions: []string{"a", "b"}}}
+
+	signature, err := ComputeRoleBundleSignature("secret", roles)
+	require.NoError(t, err)
+
+	tampered := []*Role{{Name: "test_role", Permissions: []string{"a", "b", "manage_system"}}}
+	bundle := &RoleExportBundle{SchemaVersion: RoleBundleSchemaVersion, Roles: tampered, Signature: signature}
+	require.False(t, VerifyRoleBundleSignature("secret", bundle))
+}
+
+func TestVerifyRoleBundleSignatureRejectsWrongSecret(t *testing.T) {
+	roles := []*Role{{Name: "test_role", Permissions: []string{"a", "b"}}}
+
+	signature, err := ComputeRoleBundleSignature("secret", roles)
+	require.NoError(t, err)
+
+	bundle := &RoleExportBundle{SchemaVersion: RoleBundleSchemaVersion, Roles: roles, Signature: signature}
+	require.False(t, VerifyRoleBundleSignature("other-secret", bundle))
+}
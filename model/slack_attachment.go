@@ -0,0 +1,26 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+type SlackAttachmentField struct {
+	Title string      `json:"title"`
+	Value interface{} `json:"value"`
+	Short bool        `json:"short"`
+}
+
+// SlackAttachment is a richly formatted block attached to a CommandResponse,
+// compatible with the subset of Slack's attachment schema Mattermost
+// renders. Actions, when present, are rendered as interactive buttons or
+// select menus wired up through PostAction.Integration.
+type SlackAttachment struct {
+	Id        int64                   `json:"id,omitempty"`
+	Fallback  string                  `json:"fallback,omitempty"`
+	Color     string                  `json:"color,omitempty"`
+	Pretext   string                  `json:"pretext,omitempty"`
+	Text      string                  `json:"text,omitempty"`
+	Title     string                  `json:"title,omitempty"`
+	TitleLink string                  `json:"title_link,omitempty"`
+	Fields    []*SlackAttachmentField `json:"fields,omitempty"`
+	Actions   []*PostAction           `json:"actions,omitempty"`
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+// System is a singleton name/value pair the server persists for itself,
+// such as a generated secret it only needs to create once and reuse
+// afterward.
+type System struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+const (
+	// SystemRoleBundleSigningSecretKey names the System row holding the
+	// HMAC secret ExportRoleBundle/ImportRoleBundle sign and verify role
+	// bundles with (see App.roleBundleSigningSecret).
+	SystemRoleBundleSigningSecretKey = "RoleBundleSigningSecretKey"
+)